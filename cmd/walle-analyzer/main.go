@@ -0,0 +1,12 @@
+// Command walle-analyzer runs the walle analyzer on Go packages, use -fix to remove the comments
+package main
+
+import (
+	"walle/pkg/analyzer"
+
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
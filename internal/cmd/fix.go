@@ -3,94 +3,234 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
+	"walle/internal/comment"
+	"walle/internal/patch"
 	"walle/internal/pipeline"
+	"walle/internal/report"
+	"walle/internal/secret"
 	"walle/internal/source"
+	"walle/internal/todo"
+	"walle/internal/trojan"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	fixAll             bool
-	fixPath            string
-	fixIgnoreGitIgnore bool
-	fixBaseCommit      string
+	fixAll                bool
+	fixPath               string
+	fixIgnoreGitIgnore    bool
+	fixBaseCommit         string
+	fixDryRun             bool
+	fixPatchFile          string
+	fixYes                bool
+	fixCommit             bool
+	fixAmend              bool
+	fixFixup              string
+	fixAuthor             string
+	fixCommitTemplate     string
+	fixFrom               string
+	fixOnlyRedundant      bool
+	fixMinRedundancy      float64
+	fixStale              bool
+	fixPolicy             string
+	fixRedactSecrets      bool
+	fixStripUnsafeUnicode bool
 )
 
 var fixCmd = &cobra.Command{
-	Use:   "fix",
+	Use:   "fix [files...]",
 	Short: "Trash compact comments",
 	Run: func(cmd *cobra.Command, args []string) {
-		runFix()
+		runFix(args)
 	},
 }
 
-func runFix() {
-	scanOpts := &source.ScanOptions{
-		BaseCommit: fixBaseCommit,
-		// TargetCommit is always empty (HEAD) for fix - we only remove comments that don't exist anymore
+func runFix(args []string) {
+	if countTrue(fixCommit, fixAmend, fixFixup != "") > 1 {
+		fmt.Println("Error: --commit, --amend and --fixup cannot be combined")
+		return
+	}
+	if fixFrom != "" && (fixAll || fixPath != "" || fixBaseCommit != "" || len(args) > 0) {
+		fmt.Println("Error: --from cannot be combined with --all, --path, --base or file arguments")
+		return
+	}
+
+	commitTemplate, err := pipeline.LoadCommitTemplate(fixCommitTemplate)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	// TargetCommit is always empty (HEAD) for fix - we only remove comments that don't exist anymore
+	scanOpts, err := source.BuildScanOptions(fixAll, fixPath, fixIgnoreGitIgnore, fixBaseCommit, "", args)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	out, err := newRenderer()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	root, err := source.RepoRoot()
+	if err != nil {
+		root = "."
+	}
+	policy, err := todo.FindPolicy(fixPolicy, root)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	var policyFilter func(*comment.Comment) bool
+	if policy != nil {
+		policyFilter = policy.KeepCompliant()
 	}
 
-	if fixPath != "" {
-		info, err := os.Stat(fixPath)
+	minRedundancy := fixMinRedundancy
+	if fixOnlyRedundant && minRedundancy <= 0 {
+		minRedundancy = comment.DefaultRedundancy
+	}
+	removeFilter := combineFilters(policyFilter, redundancyFilter(minRedundancy), staleFilter(fixStale))
+	pipelineOpts := pipeline.Options{
+		Verbose:  verbose,
+		Progress: scanOpts.Type == source.ScanWhole,
+		Renderer: out,
+		Filter:   removeFilter,
+		Check:    checkComment,
+	}
+	var rewriter *comment.Rewriter
+	if rewrite := fixRewrite(); rewrite != nil {
+		// Comments that are not removed, walle:keep ones included, are cleaned up in place
+		rewriter = comment.NewRewriter(removeFilter, rewrite)
+		pipelineOpts.Filter = rewriter.Filter
+		pipelineOpts.IncludeKept = true
+	}
+
+	var comments []comment.Comment
+	if fixFrom != "" {
+		r, err := report.Load(fixFrom)
 		if err != nil {
-			fmt.Printf("Error finding path: %v\n", err)
+			fmt.Printf("Error: %v\n", err)
 			return
 		}
-
-		if info.IsDir() {
-			files, err := findAllFiles(fixPath)
-			if err != nil {
-				fmt.Printf("Error listing files: %v\n", err)
-				return
-			}
-			scanOpts.SpecificFiles = files
-			// Respect gitignore when scanning a directory
-		} else {
-			scanOpts.SpecificFiles = []string{fixPath}
-			// Bypass gitignore when scanning a specific file
-			scanOpts.IgnoreGitIgnore = true
-		}
-		scanOpts.Type = source.ScanWhole
-	} else if fixAll {
-		var err error
-		files, err := findAllFiles(".")
+		comments, err = pipeline.LocatePipeline(r, pipelineOpts)
 		if err != nil {
-			fmt.Printf("Error listing files: %v\n", err)
+			fmt.Printf("Error locating comments: %v\n", err)
 			return
 		}
-		scanOpts.SpecificFiles = files
-		scanOpts.Type = source.ScanWhole
-		// Respect gitignore by default when using -a flag
 	} else {
-		scanOpts.Type = source.ScanDiff
-		// Default: respect gitignore
+		comments, err = pipeline.ScanPipeline(scanOpts, pipelineOpts)
+		if err != nil {
+			fmt.Printf("Error scanning: %v\n", err)
+			return
+		}
 	}
 
-	// Override gitignore if flag is set
-	if fixIgnoreGitIgnore {
-		scanOpts.IgnoreGitIgnore = true
+	if len(comments) == 0 {
+		fmt.Println("No comments found.")
+		return
 	}
 
-	pipelineOpts := pipeline.Options{
-		Verbose: verbose,
+	var rewrites []comment.Rewrite
+	if rewriter != nil {
+		comments, rewrites = rewriter.Split(comments)
 	}
+	edits := pipeline.PlanEditsPipeline(comments, rewrites, pipelineOpts)
 
-	comments, err := pipeline.ScanPipeline(scanOpts, pipelineOpts)
-	if err != nil {
-		fmt.Printf("Error scanning: %v\n", err)
+	if fixDryRun {
+		for _, edit := range edits {
+			out.Diff(patch.Unified(edit.Path, edit.Original, edit.Updated))
+		}
 		return
 	}
 
-	if len(comments) == 0 {
-		fmt.Println("No comments found.")
+	if fixPatchFile != "" {
+		var sb strings.Builder
+		for _, edit := range edits {
+			sb.WriteString(patch.Unified(edit.Path, edit.Original, edit.Updated))
+		}
+		if err := os.WriteFile(fixPatchFile, []byte(sb.String()), 0644); err != nil {
+			fmt.Printf("Error writing patch: %v\n", err)
+			return
+		}
+		fmt.Printf("Wrote patch for %d files to %s\n", len(edits), fixPatchFile)
 		return
 	}
 
-	err = pipeline.TrashPipeline(comments)
+	committing := fixCommit || fixAmend || fixFixup != ""
+	if committing {
+		// Refuse before writing, the commit would fail with the comments already removed
+		var files []string
+		for _, edit := range edits {
+			files = append(files, edit.Path)
+		}
+		if err := source.CheckStaged(files); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+	}
+
+	removals, redactions := 0, 0
+	for _, edit := range edits {
+		removals += len(edit.Comments)
+		redactions += len(edit.Rewrites)
+	}
+	prompt := fmt.Sprintf("Apply %d removals across %d files?", removals, len(edits))
+	if redactions > 0 {
+		prompt = fmt.Sprintf("Apply %d removals and %d redactions across %d files?", removals, redactions, len(edits))
+	}
+	if !fixYes && !confirm(prompt) {
+		fmt.Println("Aborted, no files were changed.")
+		return
+	}
+
+	written, err := pipeline.TrashPipeline(edits, pipelineOpts)
 	if err != nil {
 		fmt.Printf("Error in trash pipeline: %v\n", err)
 		return
 	}
+
+	if committing {
+		commitOpts := pipeline.CommitOptions{
+			Template: commitTemplate,
+			Author:   fixAuthor,
+			Amend:    fixAmend,
+			Fixup:    fixFixup,
+		}
+		if err := pipeline.CommitPipeline(written, commitOpts, pipelineOpts); err != nil {
+			fmt.Printf("Error committing: %v\n", err)
+		}
+	}
+}
+
+// fixRewrite returns the text changes of --strip-unsafe-unicode and --redact-secrets, nil without them
+func fixRewrite() func(string) string {
+	if !fixStripUnsafeUnicode && !fixRedactSecrets {
+		return nil
+	}
+	return func(text string) string {
+		// Strip first, zero-width characters can split a secret so it is not detected
+		if fixStripUnsafeUnicode {
+			text = trojan.Strip(text)
+		}
+		if fixRedactSecrets {
+			text = secret.RedactText(text)
+		}
+		return text
+	}
+}
+
+func countTrue(values ...bool) int {
+	count := 0
+	for _, v := range values {
+		if v {
+			count++
+		}
+	}
+	return count
 }
 
 func init() {
@@ -100,4 +240,19 @@ func init() {
 	fixCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show comments")
 	fixCmd.Flags().BoolVar(&fixIgnoreGitIgnore, "ignore-gitignore", false, "Ignore .gitignore rules")
 	fixCmd.Flags().StringVar(&fixBaseCommit, "base", "", "Base commit for comparison (target is always HEAD)")
+	fixCmd.Flags().BoolVar(&fixDryRun, "dry-run", false, "Print a unified diff of the changes without writing anything")
+	fixCmd.Flags().StringVar(&fixPatchFile, "patch", "", "Write a git apply compatible patch to this file instead of changing files")
+	fixCmd.Flags().BoolVarP(&fixYes, "yes", "y", false, "Skip the confirmation prompt")
+	fixCmd.Flags().BoolVar(&fixCommit, "commit", false, "Commit the changed files, listing the removed comments in the message")
+	fixCmd.Flags().BoolVar(&fixAmend, "amend", false, "Amend HEAD with the changed files")
+	fixCmd.Flags().StringVar(&fixFixup, "fixup", "", "Create a fixup! commit for this revision")
+	fixCmd.Flags().StringVar(&fixAuthor, "author", "", "Commit author as \"Name <email>\" (defaults to git config)")
+	fixCmd.Flags().StringVar(&fixFrom, "from", "", "Only remove the comments listed in a report from walle scan --format json")
+	fixCmd.Flags().StringVar(&fixPolicy, "policy", "", "TODO policy file, compliant TODOs are kept (defaults to "+todo.PolicyFile+" in the repository root)")
+	fixCmd.Flags().BoolVar(&fixRedactSecrets, "redact-secrets", false, "Also redact secrets in the comments that would be kept, walle:keep ones included")
+	fixCmd.Flags().BoolVar(&fixStripUnsafeUnicode, "strip-unsafe-unicode", false, "Also remove bidi controls, invisible and confusable characters from the comments that are kept")
+	fixCmd.Flags().BoolVar(&fixStale, "stale", false, "Only remove comments that refer to identifiers or files that no longer exist")
+	fixCmd.Flags().BoolVar(&fixOnlyRedundant, "only-redundant", false, "Only remove comments that repeat their code, same as --min-redundancy 0.7")
+	fixCmd.Flags().Float64Var(&fixMinRedundancy, "min-redundancy", 0, "Only remove comments with at least this redundancy score, from 0 to 1")
+	fixCmd.Flags().StringVar(&fixCommitTemplate, "commit-template", "", "File with a Go text/template for the commit message")
 }
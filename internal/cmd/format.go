@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"walle/internal/format"
+	"walle/internal/patch"
+	"walle/internal/pipeline"
+	"walle/internal/source"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	formatAll             bool
+	formatPath            string
+	formatIgnoreGitIgnore bool
+	formatBaseCommit      string
+	formatDryRun          bool
+	formatYes             bool
+	formatStyle           string
+	formatMoveTrailing    bool
+	formatWrap            int
+	formatCapitalize      bool
+)
+
+var formatCmd = &cobra.Command{
+	Use:   "format",
+	Short: "Normalize the style of comments instead of removing them",
+	Run: func(cmd *cobra.Command, args []string) {
+		runFormat()
+	},
+}
+
+func runFormat() {
+	style, err := format.ParseStyle(formatStyle)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if formatWrap < 0 {
+		fmt.Println("Error: --wrap must be a positive number of columns")
+		return
+	}
+	formatOpts := format.Options{
+		Style:        style,
+		MoveTrailing: formatMoveTrailing,
+		Wrap:         formatWrap,
+		Capitalize:   formatCapitalize,
+	}
+
+	scanOpts, err := source.BuildScanOptions(formatAll, formatPath, formatIgnoreGitIgnore, formatBaseCommit, "", nil)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	out, err := newRenderer()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	pipelineOpts := pipeline.Options{
+		Verbose:  verbose,
+		Progress: scanOpts.Type == source.ScanWhole,
+		Renderer: out,
+	}
+
+	comments, err := pipeline.ScanPipeline(scanOpts, pipelineOpts)
+	if err != nil {
+		fmt.Printf("Error scanning: %v\n", err)
+		return
+	}
+
+	if len(comments) == 0 {
+		fmt.Println("No comments found.")
+		return
+	}
+
+	edits := pipeline.FormatPlanPipeline(comments, formatOpts, pipelineOpts)
+	if len(edits) == 0 {
+		fmt.Println("All comments are already formatted.")
+		return
+	}
+
+	if formatDryRun {
+		for _, edit := range edits {
+			out.Diff(patch.Unified(edit.Path, edit.Original, edit.Updated))
+		}
+		return
+	}
+
+	changes := 0
+	for _, edit := range edits {
+		changes += len(edit.Comments)
+	}
+	if !formatYes && !confirm(fmt.Sprintf("Format %d comments across %d files?", changes, len(edits))) {
+		fmt.Println("Aborted, no files were changed.")
+		return
+	}
+
+	if err := pipeline.FormatPipeline(edits, pipelineOpts); err != nil {
+		fmt.Printf("Error formatting: %v\n", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(formatCmd)
+	formatCmd.Flags().BoolVarP(&formatAll, "all", "a", false, "Scan all files in the current directory")
+	formatCmd.Flags().StringVarP(&formatPath, "path", "p", "", "Scan a specific file or directory")
+	formatCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show comments")
+	formatCmd.Flags().BoolVar(&formatIgnoreGitIgnore, "ignore-gitignore", false, "Ignore .gitignore rules")
+	formatCmd.Flags().StringVar(&formatBaseCommit, "base", "", "Base commit for comparison (target is always HEAD)")
+	formatCmd.Flags().BoolVar(&formatDryRun, "dry-run", false, "Print a unified diff of the changes without writing anything")
+	formatCmd.Flags().BoolVarP(&formatYes, "yes", "y", false, "Skip the confirmation prompt")
+	formatCmd.Flags().StringVar(&formatStyle, "style", "", "Convert comments to line or block style (default keeps the style)")
+	formatCmd.Flags().BoolVar(&formatMoveTrailing, "move-trailing", false, "Move comments at the end of a line of code above that line")
+	formatCmd.Flags().IntVar(&formatWrap, "wrap", 0, "Reflow comment text to this many columns")
+	formatCmd.Flags().BoolVar(&formatCapitalize, "capitalize", false, "Capitalize the first letter of each comment")
+}
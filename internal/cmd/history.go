@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"walle/internal/journal"
+
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List previous fix runs that can be undone",
+	Run: func(cmd *cobra.Command, args []string) {
+		runHistory()
+	},
+}
+
+func runHistory() {
+	runs, err := journal.List()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	if len(runs) == 0 {
+		fmt.Println("No fix runs recorded.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN\tTIME\tFILES\tCOMMENTS\tSTATUS")
+	for _, run := range runs {
+		comments := 0
+		for _, entry := range run.Files {
+			comments += entry.Comments
+		}
+
+		status := "applied"
+		if run.UndoneAt != nil {
+			status = "undone"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", run.ID, run.Timestamp.Format("2006-01-02 15:04:05"), len(run.Files), comments, status)
+	}
+	tw.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"walle/internal/hook"
+	"walle/internal/pipeline"
+	"walle/internal/source"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	hookFix bool
+)
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Install or remove the git pre-commit hook",
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install a pre-commit hook that runs walle on staged files",
+	Run: func(cmd *cobra.Command, args []string) {
+		runHookInstall()
+	},
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the walle pre-commit hook and put back the hook it chained",
+	Run: func(cmd *cobra.Command, args []string) {
+		runHookUninstall()
+	},
+}
+
+var hookRunCmd = &cobra.Command{
+	Use:   "run [files...]",
+	Short: "Check staged files or the given files for new comments, used by the hook",
+	Run: func(cmd *cobra.Command, args []string) {
+		if !runHookRun(args) {
+			os.Exit(1)
+		}
+	},
+}
+
+func runHookInstall() {
+	dir, err := hook.Dir()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	result, err := hook.Install(dir, hookFix)
+	if err != nil {
+		fmt.Printf("Error installing hook: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Installed %s\n", result.Path)
+	if result.Chained {
+		fmt.Printf("The existing hook was kept and runs first\n")
+	}
+}
+
+func runHookUninstall() {
+	dir, err := hook.Dir()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	result, err := hook.Uninstall(dir)
+	if errors.Is(err, hook.ErrNotInstalled) {
+		fmt.Printf("No walle hook installed in %s\n", dir)
+		return
+	}
+	if err != nil {
+		fmt.Printf("Error uninstalling hook: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Removed %s\n", result.Path)
+	if result.Chained {
+		fmt.Printf("The previous hook was put back\n")
+	}
+}
+
+// runHookRun checks the files for new comments, or removes them with --fix.
+// It returns false when the commit should be stopped.
+func runHookRun(args []string) bool {
+	files := args
+	if len(files) == 0 {
+		staged, err := source.StagedFiles()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return false
+		}
+		files = staged
+	}
+	if len(files) == 0 {
+		return true
+	}
+
+	scanOpts, err := source.BuildScanOptions(false, "", false, "", "", files)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return false
+	}
+	// Staged files are checked as they will be committed, parts left out with git add -p do not count
+	scanOpts.Staged = len(args) == 0
+
+	out, err := newRenderer()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return false
+	}
+
+	pipelineOpts := pipeline.Options{
+		Verbose:  true,
+		Renderer: out,
+	}
+
+	comments, err := pipeline.ScanPipeline(scanOpts, pipelineOpts)
+	if err != nil {
+		fmt.Printf("Error scanning: %v\n", err)
+		return false
+	}
+	if len(comments) == 0 {
+		return true
+	}
+
+	if !hookFix {
+		fmt.Println("Commit stopped, remove the new comments with walle fix or mark the ones to keep with walle:keep.")
+		return false
+	}
+
+	edits := pipeline.PlanPipeline(comments, pipelineOpts)
+	if _, err := pipeline.TrashPipeline(edits, pipelineOpts); err != nil {
+		fmt.Printf("Error in trash pipeline: %v\n", err)
+		return false
+	}
+	fmt.Println("Commit stopped, review and stage the files walle changed, then commit again.")
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(hookCmd)
+	hookCmd.AddCommand(hookInstallCmd, hookUninstallCmd, hookRunCmd)
+	hookInstallCmd.Flags().BoolVar(&hookFix, "fix", false, "Remove new comments in the hook instead of only stopping the commit")
+	hookRunCmd.Flags().BoolVar(&hookFix, "fix", false, "Remove the new comments instead of only reporting them")
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"walle/internal/lsp"
+
+	"github.com/spf13/cobra"
+)
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server over stdio that highlights new comments",
+	Run: func(cmd *cobra.Command, args []string) {
+		runLSP()
+	},
+}
+
+func runLSP() {
+	// stdout carries the protocol, everything else goes to stderr
+	server := lsp.NewServer(os.Stdin, os.Stdout, os.Stderr)
+	if err := server.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(lspCmd)
+}
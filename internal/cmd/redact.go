@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"walle/internal/comment"
+	"walle/internal/patch"
+	"walle/internal/pipeline"
+	"walle/internal/redact"
+	"walle/internal/source"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	redactPath            string
+	redactIgnoreGitIgnore bool
+	redactBaseCommit      string
+	redactConfig          string
+	redactAudit           string
+	redactDryRun          bool
+	redactYes             bool
+)
+
+var redactCmd = &cobra.Command{
+	Use:   "redact [files...]",
+	Short: "Replace internal names, hosts and links in comments before publishing",
+	Run: func(cmd *cobra.Command, args []string) {
+		runRedact(args)
+	},
+}
+
+func runRedact(args []string) {
+	root, err := source.RepoRoot()
+	if err != nil {
+		root = "."
+	}
+	cfg, err := redact.FindConfig(redactConfig, root)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	// Every file unless --base limits it to the comments added since
+	scanOpts, err := source.BuildScanOptions(redactBaseCommit == "", redactPath, redactIgnoreGitIgnore, redactBaseCommit, "", args)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	out, err := newRenderer()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	rewriter := cfg.Rewriter()
+	pipelineOpts := pipeline.Options{
+		Progress: scanOpts.Type == source.ScanWhole,
+		Quiet:    true,
+		Renderer: out,
+		Filter:   rewriter.Filter,
+		// Nothing internal may stay behind, walle:keep or not
+		IncludeKept: true,
+	}
+	comments, err := pipeline.ScanPipeline(scanOpts, pipelineOpts)
+	if err != nil {
+		fmt.Printf("Error scanning: %v\n", err)
+		return
+	}
+
+	removals, rewrites := rewriter.Split(comments)
+	substitutions := cfg.Audit(removals, rewrites)
+	if len(substitutions) == 0 {
+		fmt.Println("Nothing to redact.")
+		return
+	}
+	edits := pipeline.PlanEditsPipeline(removals, rewrites, pipelineOpts)
+
+	if redactDryRun {
+		for _, edit := range edits {
+			out.Diff(patch.Unified(edit.Path, edit.Original, edit.Updated))
+		}
+		writeRedactAudit(auditedEdits(edits, substitutions))
+		return
+	}
+
+	if !redactYes && !confirm(fmt.Sprintf("Apply %d substitutions across %d files?", len(auditedEdits(edits, substitutions)), len(edits))) {
+		fmt.Println("Aborted, no files were changed.")
+		return
+	}
+
+	written, err := pipeline.TrashPipeline(edits, pipelineOpts)
+	if err != nil {
+		fmt.Printf("Error in trash pipeline: %v\n", err)
+		return
+	}
+	fmt.Println()
+	writeRedactAudit(auditedEdits(written, substitutions))
+}
+
+// auditedEdits returns the substitutions in the files of the edits, skipped files changed nothing
+func auditedEdits(edits []comment.FileEdit, substitutions []redact.Substitution) []redact.Substitution {
+	paths := make(map[string]bool)
+	for _, edit := range edits {
+		paths[edit.Path] = true
+	}
+	var audited []redact.Substitution
+	for _, s := range substitutions {
+		if paths[s.Path] {
+			audited = append(audited, s)
+		}
+	}
+	return audited
+}
+
+func writeRedactAudit(substitutions []redact.Substitution) {
+	if err := redact.WriteTable(os.Stdout, substitutions); err != nil {
+		fmt.Printf("Error writing audit: %v\n", err)
+		return
+	}
+	if redactAudit == "" {
+		return
+	}
+	if err := redact.WriteAudit(redactAudit, substitutions); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Wrote audit of %d substitutions to %s\n", len(substitutions), redactAudit)
+}
+
+func init() {
+	rootCmd.AddCommand(redactCmd)
+	redactCmd.Flags().StringVarP(&redactPath, "path", "p", "", "Redact a specific file or directory")
+	redactCmd.Flags().BoolVar(&redactIgnoreGitIgnore, "ignore-gitignore", false, "Ignore .gitignore rules")
+	redactCmd.Flags().StringVar(&redactBaseCommit, "base", "", "Only redact comments added since this commit")
+	redactCmd.Flags().StringVar(&redactConfig, "config", "", "Redaction rules (defaults to "+redact.ConfigFile+" in the repository root)")
+	redactCmd.Flags().StringVar(&redactAudit, "audit", "", "Also write the audit of every substitution to this JSON file")
+	redactCmd.Flags().BoolVar(&redactDryRun, "dry-run", false, "Print a unified diff and the audit without writing anything")
+	redactCmd.Flags().BoolVarP(&redactYes, "yes", "y", false, "Skip the confirmation prompt")
+}
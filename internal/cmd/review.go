@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"walle/internal/comment"
+	"walle/internal/pipeline"
+	"walle/internal/review"
+	"walle/internal/source"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	reviewAll             bool
+	reviewPath            string
+	reviewIgnoreGitIgnore bool
+	reviewBaseCommit      string
+	reviewFiles           []string
+	reviewKinds           []string
+)
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Review comments one by one before trashing them",
+	Run: func(cmd *cobra.Command, args []string) {
+		runReview()
+	},
+}
+
+func runReview() {
+	filter := review.Filter{Files: reviewFiles}
+	for _, name := range reviewKinds {
+		kind, err := comment.ParseKind(name)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+		filter.Kinds = append(filter.Kinds, kind)
+	}
+
+	// Like fix, review always compares against HEAD
+	scanOpts, err := source.BuildScanOptions(reviewAll, reviewPath, reviewIgnoreGitIgnore, reviewBaseCommit, "", nil)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	out, err := newRenderer()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	comments, err := pipeline.ScanPipeline(scanOpts, pipeline.Options{Renderer: out})
+	if err != nil {
+		fmt.Printf("Error scanning: %v\n", err)
+		return
+	}
+
+	if len(comments) == 0 {
+		fmt.Println("No comments found.")
+		return
+	}
+
+	result, err := review.Run(comments, filter, out)
+	if err != nil {
+		fmt.Printf("Error in review: %v\n", err)
+		return
+	}
+
+	if result.Aborted {
+		fmt.Println("Aborted, no files were changed.")
+		return
+	}
+
+	if len(result.Trash) == 0 && len(result.MarkKeep) == 0 {
+		fmt.Println("Nothing to do.")
+		return
+	}
+
+	if err := review.Apply(result, out); err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(reviewCmd)
+	reviewCmd.Flags().BoolVarP(&reviewAll, "all", "a", false, "Review all files in the current directory")
+	reviewCmd.Flags().StringVarP(&reviewPath, "path", "p", "", "Review a specific file or directory")
+	reviewCmd.Flags().BoolVar(&reviewIgnoreGitIgnore, "ignore-gitignore", false, "Ignore .gitignore rules")
+	reviewCmd.Flags().StringVar(&reviewBaseCommit, "base", "", "Base commit for comparison (target is always HEAD)")
+	reviewCmd.Flags().StringSliceVar(&reviewFiles, "file", nil, "Only review files matching these glob patterns")
+	reviewCmd.Flags().StringSliceVar(&reviewKinds, "kind", nil, "Only review these comment kinds: line, block, doc, directive")
+}
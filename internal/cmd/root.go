@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 	"walle/internal/languages"
+	"walle/internal/render"
 
 	"github.com/spf13/cobra"
 )
@@ -24,6 +25,17 @@ var rootCmd = &cobra.Command{
 	Long:  buildLongDescription(),
 }
 
+var colorMode string
+
+// newRenderer creates the human output renderer from the --color flag
+func newRenderer() (*render.Renderer, error) {
+	mode, err := render.ParseColorMode(colorMode)
+	if err != nil {
+		return nil, err
+	}
+	return render.New(os.Stdout, mode), nil
+}
+
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
@@ -32,6 +44,7 @@ func Execute() {
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", "auto", "Colorize output: auto, always or never (auto honours NO_COLOR)")
 
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
@@ -3,9 +3,13 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"walle/internal/comment"
 	"walle/internal/pipeline"
+	"walle/internal/report"
+	"walle/internal/secret"
 	"walle/internal/source"
+	"walle/internal/stale"
+	"walle/internal/trojan"
 
 	"github.com/spf13/cobra"
 )
@@ -17,17 +21,26 @@ var (
 	scanIgnoreGitIgnore bool
 	scanBaseCommit      string
 	scanTargetCommit    string
+	scanFormat          string
+	scanMinRedundancy   float64
+	scanStale           bool
 )
 
 var scanCmd = &cobra.Command{
-	Use:   "scan",
+	Use:   "scan [files...]",
 	Short: "Find comments without deleting them",
 	Run: func(cmd *cobra.Command, args []string) {
-		runScan()
+		runScan(args)
 	},
 }
 
-func runScan() {
+func runScan(args []string) {
+	format, err := report.ParseFormat(scanFormat)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
 	// Validate target commit is not earlier than base commit
 	if scanBaseCommit != "" && scanTargetCommit != "" {
 		if err := source.ValidateCommitOrder(scanBaseCommit, scanTargetCommit); err != nil {
@@ -36,54 +49,29 @@ func runScan() {
 		}
 	}
 
-	scanOpts := &source.ScanOptions{
-		BaseCommit:   scanBaseCommit,
-		TargetCommit: scanTargetCommit,
-	}
-
-	if scanPath != "" {
-		info, err := os.Stat(scanPath)
-		if err != nil {
-			fmt.Printf("Error finding path: %v\n", err)
-			return
-		}
-
-		if info.IsDir() {
-			files, err := findAllFiles(scanPath)
-			if err != nil {
-				fmt.Printf("Error listing files: %v\n", err)
-				return
-			}
-			scanOpts.SpecificFiles = files
-			// Respect gitignore when scanning a directory
-		} else {
-			scanOpts.SpecificFiles = []string{scanPath}
-			// Bypass gitignore when scanning a specific file
-			scanOpts.IgnoreGitIgnore = true
-		}
-		scanOpts.Type = source.ScanWhole
-	} else if scanAll {
-		var err error
-		files, err := findAllFiles(".")
-		if err != nil {
-			fmt.Printf("Error listing files: %v\n", err)
-			return
-		}
-		scanOpts.SpecificFiles = files
-		scanOpts.Type = source.ScanWhole
-		// Respect gitignore by default when using -a flag
-	} else {
-		scanOpts.Type = source.ScanDiff
-		// Default: respect gitignore
+	scanOpts, err := source.BuildScanOptions(scanAll, scanPath, scanIgnoreGitIgnore, scanBaseCommit, scanTargetCommit, args)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
 	}
 
-	// Override gitignore if flag is set
-	if scanIgnoreGitIgnore {
-		scanOpts.IgnoreGitIgnore = true
+	out, err := newRenderer()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
 	}
 
 	pipelineOpts := pipeline.Options{
-		Verbose: verbose,
+		Verbose:  verbose,
+		Progress: scanOpts.Type == source.ScanWhole,
+		Renderer: out,
+		Filter:   combineFilters(redundancyFilter(scanMinRedundancy), staleFilter(scanStale)),
+		Check:    checkComment,
+	}
+	if format == report.FormatJSON {
+		// stdout only holds the report, warnings go to stderr
+		pipelineOpts.Quiet = true
+		pipelineOpts.Renderer = out.WithWriter(os.Stderr)
 	}
 
 	comments, err := pipeline.ScanPipeline(scanOpts, pipelineOpts)
@@ -92,23 +80,65 @@ func runScan() {
 		return
 	}
 
+	if format == report.FormatJSON {
+		if err := report.Write(os.Stdout, report.New(comments)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		}
+		return
+	}
+
 	if len(comments) == 0 {
 		fmt.Println("No comments found.")
 	}
 }
 
-func findAllFiles(root string) ([]string, error) {
-	var files []string
-	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if !d.IsDir() {
-			files = append(files, path)
+// checkComment adds the findings of the checks that run on every scan, secrets and unsafe unicode
+func checkComment(c *comment.Comment) {
+	secret.Annotate(c)
+	trojan.Annotate(c)
+}
+
+// redundancyFilter keeps the comments scoring at least min, nil when min does not filter anything
+func redundancyFilter(min float64) func(*comment.Comment) bool {
+	if min <= 0 {
+		return nil
+	}
+	return func(c *comment.Comment) bool {
+		return c.Redundancy >= min
+	}
+}
+
+// staleFilter keeps the comments that refer to code or files that no longer exist, nil when disabled
+func staleFilter(enabled bool) func(*comment.Comment) bool {
+	if !enabled {
+		return nil
+	}
+	root, err := source.RepoRoot()
+	if err != nil {
+		root = "."
+	}
+	return stale.NewResolver(root).Filter(true)
+}
+
+// combineFilters returns a filter that keeps the comments every filter keeps, nil filters are skipped
+func combineFilters(filters ...func(*comment.Comment) bool) func(*comment.Comment) bool {
+	var active []func(*comment.Comment) bool
+	for _, filter := range filters {
+		if filter != nil {
+			active = append(active, filter)
 		}
+	}
+	if len(active) == 0 {
 		return nil
-	})
-	return files, err
+	}
+	return func(c *comment.Comment) bool {
+		for _, filter := range active {
+			if !filter(c) {
+				return false
+			}
+		}
+		return true
+	}
 }
 
 func init() {
@@ -119,4 +149,7 @@ func init() {
 	scanCmd.Flags().BoolVar(&scanIgnoreGitIgnore, "ignore-gitignore", false, "Ignore .gitignore rules")
 	scanCmd.Flags().StringVar(&scanBaseCommit, "base", "", "Base commit for comparison")
 	scanCmd.Flags().StringVar(&scanTargetCommit, "target", "", "Target commit for comparison")
+	scanCmd.Flags().StringVar(&scanFormat, "format", "text", "Output format: text or json")
+	scanCmd.Flags().BoolVar(&scanStale, "stale", false, "Only report comments that refer to identifiers or files that no longer exist")
+	scanCmd.Flags().Float64Var(&scanMinRedundancy, "min-redundancy", 0, "Only report comments with at least this redundancy score, from 0 to 1")
 }
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"walle/internal/serve"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveSocket    string
+	serveCacheSize int
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Answer scan and fix requests over a unix socket or localhost TCP, keeping parsers warm",
+	Run: func(cmd *cobra.Command, args []string) {
+		runServe()
+	},
+}
+
+func runServe() {
+	if serveSocket == "" {
+		fmt.Println("Error: --socket is required")
+		return
+	}
+
+	listener, err := serve.Listen(serveSocket)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	server, err := serve.NewServer(".", serveCacheSize, log.New(os.Stderr, "walle serve: ", log.LstdFlags))
+	if err != nil {
+		listener.Close()
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupt
+		server.Close()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", serveSocket)
+	if err := server.Serve(listener); err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&serveSocket, "socket", "", "Unix socket path or localhost TCP address to listen on")
+	serveCmd.Flags().IntVar(&serveCacheSize, "cache-size", serve.DefaultCacheSize, "Number of scanned files to keep in memory")
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"walle/internal/pipeline"
+	"walle/internal/source"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	stashAll             bool
+	stashPath            string
+	stashIgnoreGitIgnore bool
+)
+
+var stashCmd = &cobra.Command{
+	Use:   "stash",
+	Short: "Remove comments and keep them in a sidecar file to unstash later",
+	Run: func(cmd *cobra.Command, args []string) {
+		runStash()
+	},
+}
+
+func runStash() {
+	scanOpts, err := source.BuildScanOptions(stashAll, stashPath, stashIgnoreGitIgnore, "", "", nil)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	out, err := newRenderer()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	pipelineOpts := pipeline.Options{
+		Verbose:  verbose,
+		Progress: scanOpts.Type == source.ScanWhole,
+		Renderer: out,
+	}
+
+	comments, err := pipeline.ScanPipeline(scanOpts, pipelineOpts)
+	if err != nil {
+		fmt.Printf("Error scanning: %v\n", err)
+		return
+	}
+
+	if len(comments) == 0 {
+		fmt.Println("No comments found.")
+		return
+	}
+
+	edits := pipeline.PlanPipeline(comments, pipelineOpts)
+	if err := pipeline.StashPipeline(edits, pipelineOpts); err != nil {
+		fmt.Printf("Error stashing: %v\n", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(stashCmd)
+	stashCmd.Flags().BoolVarP(&stashAll, "all", "a", false, "Scan all files in the current directory")
+	stashCmd.Flags().StringVarP(&stashPath, "path", "p", "", "Scan a specific file or directory")
+	stashCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show comments")
+	stashCmd.Flags().BoolVar(&stashIgnoreGitIgnore, "ignore-gitignore", false, "Ignore .gitignore rules")
+}
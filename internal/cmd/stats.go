@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"walle/internal/pipeline"
+	"walle/internal/source"
+	"walle/internal/stats"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsAll             bool
+	statsPath            string
+	statsIgnoreGitIgnore bool
+	statsBaseCommit      string
+	statsTargetCommit    string
+	statsFormat          string
+	statsGroupBy         string
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Report code and comment metrics per language, directory or file",
+	Run: func(cmd *cobra.Command, args []string) {
+		runStats()
+	},
+}
+
+func runStats() {
+	format, err := stats.ParseFormat(statsFormat)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	groupBy, err := stats.ParseGroupBy(statsGroupBy)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	if statsBaseCommit != "" && statsTargetCommit != "" {
+		if err := source.ValidateCommitOrder(statsBaseCommit, statsTargetCommit); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+	}
+
+	scanOpts, err := source.BuildScanOptions(statsAll, statsPath, statsIgnoreGitIgnore, statsBaseCommit, statsTargetCommit, nil)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	out, err := newRenderer()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	// The report goes to stdout, keep it parseable
+	out = out.WithWriter(os.Stderr)
+
+	files, skipped, err := pipeline.StatsPipeline(scanOpts, pipeline.Options{Renderer: out})
+	if err != nil {
+		fmt.Printf("Error scanning: %v\n", err)
+		return
+	}
+	if len(skipped) > 0 {
+		out.Info("Skipped %d files in languages walle doesn't parse", len(skipped))
+	}
+
+	report := stats.Aggregate(files, groupBy)
+	if err := stats.Write(os.Stdout, report, format); err != nil {
+		fmt.Printf("Error writing report: %v\n", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().BoolVarP(&statsAll, "all", "a", false, "Count all files in the current directory")
+	statsCmd.Flags().StringVarP(&statsPath, "path", "p", "", "Count a specific file or directory")
+	statsCmd.Flags().BoolVar(&statsIgnoreGitIgnore, "ignore-gitignore", false, "Ignore .gitignore rules")
+	statsCmd.Flags().StringVar(&statsBaseCommit, "base", "", "Base commit for comparison")
+	statsCmd.Flags().StringVar(&statsTargetCommit, "target", "", "Target commit for comparison")
+	statsCmd.Flags().StringVar(&statsFormat, "format", "table", "Output format: table, json or csv")
+	statsCmd.Flags().StringVar(&statsGroupBy, "group-by", "lang", "Group rows by dir, lang or file")
+}
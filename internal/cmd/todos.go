@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+	"walle/internal/pipeline"
+	"walle/internal/source"
+	"walle/internal/todo"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	todosPath            string
+	todosIgnoreGitIgnore bool
+	todosBaseCommit      string
+	todosFormat          string
+	todosPolicy          string
+	todosViolations      bool
+)
+
+var todosCmd = &cobra.Command{
+	Use:   "todos [files...]",
+	Short: "List TODO, FIXME, HACK and XXX comments with owner, ticket and age",
+	Run: func(cmd *cobra.Command, args []string) {
+		runTodos(args)
+	},
+}
+
+func runTodos(args []string) {
+	format, err := todo.ParseFormat(todosFormat)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	root, err := source.RepoRoot()
+	if err != nil {
+		root = "."
+	}
+	policy, err := todo.FindPolicy(todosPolicy, root)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	// Every file unless --base limits the list to the TODOs added since
+	scanOpts, err := source.BuildScanOptions(todosBaseCommit == "", todosPath, todosIgnoreGitIgnore, todosBaseCommit, "", args)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	out, err := newRenderer()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	comments, err := pipeline.ScanPipeline(scanOpts, pipeline.Options{Quiet: true, Renderer: out.WithWriter(os.Stderr)})
+	if err != nil {
+		fmt.Printf("Error scanning: %v\n", err)
+		return
+	}
+
+	var todos []todo.Todo
+	for _, c := range comments {
+		t, ok := todo.Parse(c)
+		if !ok {
+			continue
+		}
+		if policy != nil {
+			policy.Check(&t)
+		} else {
+			t.Compliant = true
+		}
+		if todosViolations && t.Compliant {
+			continue
+		}
+		todos = append(todos, t)
+	}
+	sort.SliceStable(todos, func(i, j int) bool {
+		if todos[i].Path != todos[j].Path {
+			return todos[i].Path < todos[j].Path
+		}
+		return todos[i].Line < todos[j].Line
+	})
+
+	if blamer, err := source.NewBlamer(); err == nil {
+		if err := todo.Blame(todos, blamer, time.Now()); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return
+		}
+	}
+
+	if err := todo.Write(os.Stdout, todos, format); err != nil {
+		fmt.Printf("Error writing report: %v\n", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(todosCmd)
+	todosCmd.Flags().StringVarP(&todosPath, "path", "p", "", "List a specific file or directory")
+	todosCmd.Flags().BoolVar(&todosIgnoreGitIgnore, "ignore-gitignore", false, "Ignore .gitignore rules")
+	todosCmd.Flags().StringVar(&todosBaseCommit, "base", "", "Only list TODOs added since this commit")
+	todosCmd.Flags().StringVar(&todosFormat, "format", "table", "Output format: table, json or csv")
+	todosCmd.Flags().StringVar(&todosPolicy, "policy", "", "Policy file (defaults to "+todo.PolicyFile+" in the repository root)")
+	todosCmd.Flags().BoolVar(&todosViolations, "violations", false, "Only list TODOs that break the policy")
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"walle/internal/journal"
+
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [run-id]",
+	Short: "Restore the files changed by the last fix, or by the given run",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runUndo(args)
+	},
+}
+
+func runUndo(args []string) {
+	out, err := newRenderer()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	var run journal.Run
+	if len(args) == 1 {
+		run, err = journal.Load(args[0])
+	} else {
+		run, err = journal.Latest()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	if run.UndoneAt != nil {
+		fmt.Printf("Run %s was already undone at %s.\n", run.ID, run.UndoneAt.Format("2006-01-02 15:04:05"))
+		return
+	}
+
+	restored, refused, err := journal.Restore(run)
+	for _, path := range restored {
+		out.Success("✅ Restored %s", path)
+	}
+	for _, r := range refused {
+		out.Warning("⚠️  Not restoring %s: %s", r.Path, r.Reason)
+	}
+	if err != nil {
+		fmt.Printf("Error updating journal: %v\n", err)
+		return
+	}
+
+	out.Info("")
+	out.Summary("↩️  Restored %d of %d files from run %s.", len(restored), len(restored)+len(refused), run.ID)
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+}
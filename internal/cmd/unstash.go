@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"walle/internal/pipeline"
+
+	"github.com/spf13/cobra"
+)
+
+var unstashCmd = &cobra.Command{
+	Use:   "unstash",
+	Short: "Put comments stashed by walle stash back into their files",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runUnstash()
+	},
+}
+
+func runUnstash() {
+	out, err := newRenderer()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	if err := pipeline.UnstashPipeline(pipeline.Options{Renderer: out}); err != nil {
+		fmt.Printf("Error unstashing: %v\n", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(unstashCmd)
+}
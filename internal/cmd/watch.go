@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+	"walle/internal/watch"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchFix             bool
+	watchIgnoreGitIgnore bool
+	watchDebounce        time.Duration
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keep a live summary of the comments added relative to HEAD",
+	Run: func(cmd *cobra.Command, args []string) {
+		runWatch()
+	},
+}
+
+func runWatch() {
+	out, err := newRenderer()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	watcher, err := watch.New(watch.Options{
+		Fix:             watchFix,
+		Debounce:        watchDebounce,
+		IgnoreGitIgnore: watchIgnoreGitIgnore,
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	defer watcher.Close()
+
+	if isatty.IsTerminal(os.Stdout.Fd()) {
+		err = watch.RunTUI(watcher, out)
+	} else {
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		err = watch.RunPlain(watcher, out, interrupt)
+	}
+	if err != nil {
+		fmt.Printf("Error watching: %v\n", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().BoolVar(&watchFix, "fix", false, "Remove new comments from files when they are saved")
+	watchCmd.Flags().BoolVar(&watchIgnoreGitIgnore, "ignore-gitignore", false, "Ignore .gitignore rules")
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", watch.DefaultDebounce, "Wait this long for file events to settle before rescanning")
+}
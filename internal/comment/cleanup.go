@@ -0,0 +1,274 @@
+package comment
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strings"
+	"walle/internal/languages"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// cleanupRule holds the per-language formatting rules applied around removed comments
+type cleanupRule struct {
+	// wrappers are node types that are removed together with a comment when it is all they contain
+	wrappers []string
+	// bodies are node types that get the filler statement when removal leaves them empty
+	bodies []string
+	filler string
+	// fillerNode is the node type of the filler, ignored when verifying
+	fillerNode string
+}
+
+var cleanupRules = map[string]cleanupRule{
+	// {/* comment */} in JSX would otherwise leave an empty {} behind
+	"javascript": {wrappers: []string{"jsx_expression"}},
+	"tsx":        {wrappers: []string{"jsx_expression"}},
+	"python":     {bodies: []string{"block"}, filler: "pass", fillerNode: "pass_statement"},
+	"ruby":       {bodies: []string{"do_block", "block", "begin"}, filler: "nil", fillerNode: "nil"},
+	"elixir":     {bodies: []string{"do_block"}, filler: "nil", fillerNode: "nil"},
+}
+
+// cut is a position in the output where content was removed
+type cut struct {
+	offset    int
+	wholeLine bool
+}
+
+func ruleForFile(filePath string) cleanupRule {
+	return cleanupRules[languages.GetLanguageNameForExtension(strings.ToLower(filepath.Ext(filePath)))]
+}
+
+// expandWrappers replaces comments that are the only content of a wrapper node by the wrapper itself.
+// It also returns the starts of the widened comments.
+func expandWrappers(rule cleanupRule, filePath string, content []byte, comments []Comment) ([]Comment, map[uint32]bool) {
+	if len(rule.wrappers) == 0 || len(comments) == 0 {
+		return comments, nil
+	}
+
+	tree, err := ParseFile(filePath, content)
+	if err != nil {
+		return comments, nil
+	}
+	defer tree.Close()
+
+	removed := make(map[uint32]bool)
+	for _, c := range comments {
+		removed[c.StartByte] = true
+	}
+
+	expanded := make([]Comment, 0, len(comments))
+	seen := make(map[uint32]bool)
+	wrapped := make(map[uint32]bool)
+	for _, c := range comments {
+		node := DescendantForByteRange(tree.RootNode(), c.StartByte, c.EndByte)
+		parent := node.Parent()
+		if parent != nil && isWrapper(rule, parent) && onlyRemovedComments(parent, removed) {
+			c.StartByte = parent.StartByte()
+			c.EndByte = parent.EndByte()
+			wrapped[c.StartByte] = true
+		}
+		if seen[c.StartByte] {
+			continue
+		}
+		seen[c.StartByte] = true
+		expanded = append(expanded, c)
+	}
+	return expanded, wrapped
+}
+
+func isWrapper(rule cleanupRule, node *sitter.Node) bool {
+	for _, wrapper := range rule.wrappers {
+		if node.Type() == wrapper {
+			return true
+		}
+	}
+	return false
+}
+
+func onlyRemovedComments(node *sitter.Node, removed map[uint32]bool) bool {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		if !IsCommentNode(child) || !removed[child.StartByte()] {
+			return false
+		}
+	}
+	return true
+}
+
+// cleanup tidies the lines around the cuts: touched lines lose their trailing whitespace,
+// lines left empty are dropped and blank runs joined by a removal are collapsed.
+// Lines away from the cuts are never changed.
+func cleanup(output []byte, cuts []cut) []byte {
+	if len(cuts) == 0 {
+		return output
+	}
+
+	// The byte order mark stays in front of whatever remains of the first line
+	bom := output[:bomLength(output)]
+	output = output[len(bom):]
+
+	lines := splitLines(output)
+	deleted := make([]bool, len(lines))
+	endsWithNewline := len(output) > 0 && output[len(output)-1] == '\n'
+	starts := make([]int, len(lines))
+	offset := 0
+	for i, line := range lines {
+		starts[i] = offset
+		offset += len(line)
+	}
+
+	// gaps are indexes of lines that directly follow a removed line
+	var gaps []int
+	for _, c := range cuts {
+		index := lineIndex(starts, len(output), endsWithNewline, max(c.offset-len(bom), 0))
+		if c.wholeLine {
+			gaps = append(gaps, index)
+			continue
+		}
+		if index >= len(lines) {
+			continue
+		}
+
+		lines[index] = trimTrailingSpace(lines[index])
+		if isBlank(lines[index]) && !deleted[index] {
+			// The line only held comments
+			deleted[index] = true
+			gaps = append(gaps, index+1)
+		}
+	}
+
+	collapseBlankRuns(lines, deleted, gaps)
+
+	var sb bytes.Buffer
+	sb.Write(bom)
+	for i, line := range lines {
+		if !deleted[i] {
+			sb.Write(line)
+		}
+	}
+	result := sb.Bytes()
+
+	// Never leave a file without its final newline when it had one, in the style it had
+	if endsWithNewline && len(result) > len(bom) && result[len(result)-1] != '\n' {
+		if bytes.HasSuffix(output, []byte("\r\n")) {
+			result = append(result, '\r')
+		}
+		result = append(result, '\n')
+	}
+	return result
+}
+
+// collapseBlankRuns shrinks every blank run that contains a gap to the longest part of it that existed
+// before the removal. Runs at the start or end of the file are removed entirely.
+func collapseBlankRuns(lines [][]byte, deleted []bool, gaps []int) {
+	isGap := make(map[int]bool)
+	for _, g := range gaps {
+		isGap[g] = true
+	}
+
+	i := 0
+	for i <= len(lines) {
+		if i < len(lines) && (deleted[i] || !isBlank(lines[i])) && !isGap[i] {
+			i++
+			continue
+		}
+
+		// Collect the blank run starting here, split into segments by the gaps inside it
+		var run []int
+		var segments []int
+		segment := 0
+		touched := false
+		j := i
+		for ; j <= len(lines); j++ {
+			if isGap[j] {
+				touched = true
+				segments = append(segments, segment)
+				segment = 0
+			}
+			if j == len(lines) {
+				break
+			}
+			if deleted[j] {
+				continue
+			}
+			if !isBlank(lines[j]) {
+				break
+			}
+			run = append(run, j)
+			segment++
+		}
+		segments = append(segments, segment)
+
+		if touched && len(run) > 0 {
+			target := 0
+			for _, s := range segments {
+				target = max(target, s)
+			}
+			if atStart(lines, deleted, run[0]) || j == len(lines) {
+				target = 0
+			}
+			for k := target; k < len(run); k++ {
+				deleted[run[k]] = true
+			}
+		}
+
+		i = j + 1
+	}
+}
+
+func atStart(lines [][]byte, deleted []bool, index int) bool {
+	for i := 0; i < index; i++ {
+		if !deleted[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// lineIndex returns the line containing offset, or len(starts) when offset is past the last line start
+// and the content ends with a newline
+func lineIndex(starts []int, total int, endsWithNewline bool, offset int) int {
+	if offset >= total && (endsWithNewline || len(starts) == 0) {
+		return len(starts)
+	}
+	index := sort.Search(len(starts), func(i int) bool { return starts[i] > offset }) - 1
+	return max(index, 0)
+}
+
+func splitLines(content []byte) [][]byte {
+	var lines [][]byte
+	for len(content) > 0 {
+		i := bytes.IndexByte(content, '\n')
+		if i < 0 {
+			lines = append(lines, content)
+			break
+		}
+		lines = append(lines, content[:i+1])
+		content = content[i+1:]
+	}
+	return lines
+}
+
+// trimTrailingSpace removes spaces and tabs before the line ending, keeping \n or \r\n
+func trimTrailingSpace(line []byte) []byte {
+	ending := ""
+	body := line
+	if bytes.HasSuffix(body, []byte("\r\n")) {
+		ending, body = "\r\n", body[:len(body)-2]
+	} else if bytes.HasSuffix(body, []byte("\n")) {
+		ending, body = "\n", body[:len(body)-1]
+	}
+	trimmed := bytes.TrimRight(body, " \t")
+	if len(trimmed) < len(body) && bytes.HasSuffix(trimmed, []byte("\\")) {
+		// A backslash at the end of the line would join the next one
+		trimmed = body[:len(trimmed)+1]
+	}
+	body = trimmed
+	return append(append([]byte{}, body...), ending...)
+}
+
+func isBlank(line []byte) bool {
+	return len(bytes.TrimSpace(line)) == 0
+}
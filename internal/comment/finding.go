@@ -0,0 +1,40 @@
+package comment
+
+// Severity ranks how urgent a finding is
+type Severity int
+
+const (
+	SeverityLow Severity = iota
+	SeverityMedium
+	SeverityHigh
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	}
+	return "unknown"
+}
+
+// Finding is a problem a check found in a comment, such as a reference to code that no longer exists
+type Finding struct {
+	// Check names the check that reported it, such as "stale"
+	Check    string
+	Severity Severity
+	Message  string
+}
+
+// HasFinding reports whether a check reported a finding for the comment
+func (c Comment) HasFinding(check string) bool {
+	for _, f := range c.Findings {
+		if f.Check == check {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package comment
+
+import (
+	"fmt"
+	"strings"
+	"walle/internal/source"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// assignIDs gives each comment of a file, sorted by offset, an ID that survives unrelated edits.
+// The ID hashes the path relative to the repository root, the whitespace normalized text and the
+// enclosing symbol, so it doesn't depend on the directory walle runs in. Comments that would share
+// an ID are numbered in file order.
+func assignIDs(filePath string, comments []Comment) {
+	if len(comments) == 0 {
+		return
+	}
+	path := source.RepoPath(filePath)
+	seen := make(map[string]int)
+	for i := range comments {
+		c := &comments[i]
+		key := path + "\x00" + NormalizeText(c.Text) + "\x00" + c.Symbol
+		id := contentHash([]byte(key))[:12]
+
+		seen[id]++
+		if n := seen[id]; n > 1 {
+			id = fmt.Sprintf("%s-%d", id, n)
+		}
+		c.ID = id
+	}
+}
+
+// NormalizeText collapses the whitespace in a comment so reindenting it does not change its ID
+func NormalizeText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// enclosingSymbol returns the names of the declarations around a node from the outside in, joined by dots
+func enclosingSymbol(node *sitter.Node, content []byte) string {
+	var names []string
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		if name := parent.ChildByFieldName("name"); name != nil && name.StartByte() >= parent.StartByte() {
+			names = append([]string{name.Content(content)}, names...)
+		}
+	}
+	return strings.Join(names, ".")
+}
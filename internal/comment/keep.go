@@ -0,0 +1,57 @@
+package comment
+
+import (
+	"sort"
+	"strings"
+)
+
+// KeepMarker in a comment tells WALL-E to never report or remove it
+const KeepMarker = "walle:keep"
+
+// WithoutKept returns the comments that don't have the keep marker, for what reports or removes comments
+func WithoutKept(comments []Comment) []Comment {
+	var kept []Comment
+	for _, c := range comments {
+		if !strings.Contains(c.Text, KeepMarker) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// KeepRewrite returns the rewrite that adds the keep marker to a comment, so marking comments and
+// removing others is a single edit of their file
+func KeepRewrite(c Comment) Rewrite {
+	text := InsertKeepMarkers([]byte(c.Text), []Comment{{StartByte: 0, EndByte: uint32(len(c.Text))}})
+	return Rewrite{Comment: c, Text: string(text)}
+}
+
+// InsertKeepMarkers returns a copy of content with the keep marker added to each comment,
+// before the closing marker of block comments and at the end of line comments
+func InsertKeepMarkers(content []byte, comments []Comment) []byte {
+	sorted := make([]Comment, len(comments))
+	copy(sorted, comments)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartByte > sorted[j].StartByte
+	})
+
+	output := make([]byte, len(content))
+	copy(output, content)
+	for _, c := range sorted {
+		pos := keepMarkerOffset(output, c)
+		output = append(output[:pos], append([]byte(" "+KeepMarker), output[pos:]...)...)
+	}
+	return output
+}
+
+func keepMarkerOffset(content []byte, c Comment) uint32 {
+	text := string(content[c.StartByte:c.EndByte])
+	body := strings.TrimRight(text, " \t\r\n")
+	for _, marker := range closeMarkers {
+		if strings.HasSuffix(body, marker) {
+			body = strings.TrimRight(body[:len(body)-len(marker)], " \t\r\n")
+			break
+		}
+	}
+	return c.StartByte + uint32(len(body))
+}
@@ -0,0 +1,143 @@
+package comment
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Kind describes what sort of comment a node is
+type Kind int
+
+const (
+	KindLine Kind = iota
+	KindBlock
+	KindDoc
+	KindDirective
+)
+
+// AllKinds lists every comment kind in display order
+var AllKinds = []Kind{KindLine, KindBlock, KindDoc, KindDirective}
+
+func (k Kind) String() string {
+	switch k {
+	case KindLine:
+		return "line"
+	case KindBlock:
+		return "block"
+	case KindDoc:
+		return "doc"
+	case KindDirective:
+		return "directive"
+	}
+	return "unknown"
+}
+
+// ParseKind converts a kind name such as "doc" back into a Kind
+func ParseKind(name string) (Kind, error) {
+	for _, kind := range AllKinds {
+		if kind.String() == name {
+			return kind, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown comment kind %q (expected line, block, doc or directive)", name)
+}
+
+// openMarkers are the comment openers across the supported grammars, longest first
+var openMarkers = []string{"<!--", "///", "//!", "/**", "/*!", "(**", "{-|", "//", "/*", "(*", "{-", "--", "#", ";"}
+
+// closeMarkers are the comment closers across the supported grammars
+var closeMarkers = []string{"-->", "*/", "*)", "-}"}
+
+// directivePrefixes are comment bodies that tools act upon, removing them changes behaviour
+var directivePrefixes = []string{
+	"go:", "+build", "nolint", "lint:", "eslint-", "eslint ", "@ts-", "prettier-ignore",
+	"noqa", "type:", "pylint:", "mypy:", "pyright:", "rubocop:", "frozen_string_literal:",
+	"-*-", "clang-format", "NOLINT", "istanbul ", "c8 ", "swiftlint:", "@formatter:",
+	"language=", "walle:",
+}
+
+// Classify determines the kind of comment from its grammar node type and text
+func Classify(nodeType, text string) Kind {
+	trimmed := strings.TrimSpace(text)
+
+	if isDirective(trimmed) {
+		return KindDirective
+	}
+	if isDoc(trimmed) {
+		return KindDoc
+	}
+	if nodeType == "block_comment" || nodeType == "multiline_comment" || isBlockSyntax(trimmed) {
+		return KindBlock
+	}
+	return KindLine
+}
+
+// StripMarkers returns the text of a comment without its opening and closing markers
+func StripMarkers(text string) string {
+	start, end := BodyRange(text)
+	return text[start:end]
+}
+
+// BodyRange returns the byte range of the text StripMarkers keeps
+func BodyRange(text string) (int, int) {
+	start := len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))
+	end := len(strings.TrimRightFunc(text, unicode.IsSpace))
+	if start >= end {
+		return end, end
+	}
+	for _, marker := range openMarkers {
+		if strings.HasPrefix(text[start:end], marker) {
+			start += len(marker)
+			break
+		}
+	}
+	for _, marker := range closeMarkers {
+		if strings.HasSuffix(text[start:end], marker) {
+			end -= len(marker)
+			break
+		}
+	}
+	body := strings.TrimRightFunc(text[start:end], unicode.IsSpace)
+	end = start + len(body)
+	start = end - len(strings.TrimLeftFunc(body, unicode.IsSpace))
+	return start, end
+}
+
+func isDirective(trimmed string) bool {
+	if strings.HasPrefix(trimmed, "#!") {
+		return true
+	}
+	// Go directives must not have a space after the slashes
+	if strings.HasPrefix(trimmed, "//go:") || strings.HasPrefix(trimmed, "//line ") {
+		return true
+	}
+	body := StripMarkers(trimmed)
+	for _, prefix := range directivePrefixes {
+		if strings.HasPrefix(body, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDoc(trimmed string) bool {
+	if strings.HasPrefix(trimmed, "/**") && !strings.HasPrefix(trimmed, "/**/") {
+		return true
+	}
+	for _, prefix := range []string{"///", "//!", "/*!", "(**", "{-|"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlockSyntax(trimmed string) bool {
+	for _, prefix := range []string{"/*", "<!--", "(*", "{-"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -6,4 +6,31 @@ type Comment struct {
 	Line      int
 	StartByte uint32
 	EndByte   uint32
+	Kind      Kind
+	// ID identifies the comment across scans, see assignIDs
+	ID string
+	// Symbol is the qualified name of the declaration enclosing the comment, if any
+	Symbol string
+	// FileHash is the sha256 of the file content the comment was found in
+	FileHash string
+	// Redundancy scores from 0 to 1 how much the comment only repeats the code it describes
+	Redundancy float64
+	// Findings are the problems checks such as stale reference detection found in the comment
+	Findings []Finding
+}
+
+// FileEdit is the planned result of removing or rewriting comments in a single file
+type FileEdit struct {
+	Path     string
+	Original []byte
+	Updated  []byte
+	Comments []Comment
+	Rewrites []Rewrite
+	Repairs  []Repair
+}
+
+// Repair is a change made around a removed comment so the file stays valid
+type Repair struct {
+	Line        int
+	Description string
 }
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package comment
+
+import "os"
+
+// keepOwner is a no-op on platforms without unix file ownership
+func keepOwner(f *os.File, info os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package comment
+
+import (
+	"os"
+	"syscall"
+)
+
+// keepOwner gives the file the owner and group described by info, when they differ from its own
+func keepOwner(f *os.File, info os.FileInfo) error {
+	want, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	current, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if have, ok := current.Sys().(*syscall.Stat_t); ok && have.Uid == want.Uid && have.Gid == want.Gid {
+		return nil
+	}
+	return f.Chown(int(want.Uid), int(want.Gid))
+}
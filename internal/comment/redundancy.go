@@ -0,0 +1,179 @@
+package comment
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// DefaultRedundancy is the score from which a comment counts as only repeating its code
+const DefaultRedundancy = 0.7
+
+// stopWords carry no meaning on their own and are left out of the score
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "this": true, "that": true, "these": true, "those": true,
+	"to": true, "of": true, "and": true, "or": true, "in": true, "on": true, "at": true, "by": true,
+	"with": true, "from": true, "is": true, "are": true, "be": true, "been": true, "was": true,
+	"were": true, "it": true, "its": true, "we": true, "our": true, "you": true, "your": true,
+	"will": true, "should": true, "can": true, "here": true, "there": true, "then": true, "now": true,
+	"just": true, "so": true, "as": true, "into": true, "up": true, "do": true, "does": true,
+	"over": true, "all": true, "some": true, "my": true,
+}
+
+// operatorWords are the words a comment uses for an operator in the code
+var operatorWords = map[string][]string{
+	"++":     {"increment", "increase", "add"},
+	"--":     {"decrement", "decrease", "subtract"},
+	"+=":     {"increment", "increase", "add"},
+	"-=":     {"decrement", "decrease", "subtract"},
+	"=":      {"set", "assign", "store"},
+	":=":     {"set", "assign", "store", "create"},
+	"==":     {"equal", "check", "compare"},
+	"!=":     {"equal", "check", "compare"},
+	"!":      {"not"},
+	"if":     {"check"},
+	"for":    {"loop", "iterate", "each"},
+	"while":  {"loop", "iterate"},
+	"range":  {"loop", "iterate", "each"},
+	"return": {"return", "get"},
+}
+
+// maxDescribedRows is the height of a statement whose every line counts as described by a comment,
+// only the first line of taller nodes such as functions is used
+const maxDescribedRows = 3
+
+// redundancy scores from 0 to 1 how much of a comment only repeats the code it describes: the
+// share of its words found among the identifiers, literals and operators of the next statement,
+// or of the statement before a trailing comment
+func redundancy(node *sitter.Node, content []byte, kind Kind) float64 {
+	if kind == KindDirective {
+		return 0
+	}
+	words := commentWords(StripMarkers(node.Content(content)))
+	if len(words) == 0 {
+		return 0
+	}
+	target := describedNode(node)
+	if target == nil {
+		return 0
+	}
+
+	code := make(map[string]bool)
+	lastRow := target.StartPoint().Row
+	if target.EndPoint().Row-target.StartPoint().Row < maxDescribedRows {
+		lastRow = target.EndPoint().Row
+	}
+	collectCodeWords(target, content, lastRow, code)
+
+	matched := 0
+	for _, word := range words {
+		if matchesCode(word, code) {
+			matched++
+		}
+	}
+	return math.Round(float64(matched)/float64(len(words))*100) / 100
+}
+
+// describedNode returns the statement before a trailing comment, or the one after a comment on its own line
+func describedNode(node *sitter.Node) *sitter.Node {
+	if prev := node.PrevNamedSibling(); prev != nil && !IsCommentNode(prev) && prev.EndPoint().Row == node.StartPoint().Row {
+		return prev
+	}
+	for next := node.NextNamedSibling(); next != nil; next = next.NextNamedSibling() {
+		if !IsCommentNode(next) {
+			return next
+		}
+	}
+	return nil
+}
+
+// collectCodeWords adds the words of every leaf of node up to lastRow
+func collectCodeWords(node *sitter.Node, content []byte, lastRow uint32, code map[string]bool) {
+	if node.StartPoint().Row > lastRow || IsCommentNode(node) {
+		return
+	}
+	if node.ChildCount() == 0 {
+		text := node.Content(content)
+		for _, word := range operatorWords[text] {
+			code[word] = true
+		}
+		for _, word := range splitWords(text) {
+			code[stem(word)] = true
+		}
+		return
+	}
+	for i := 0; i < int(node.ChildCount()); i++ {
+		collectCodeWords(node.Child(i), content, lastRow, code)
+	}
+}
+
+// commentWords returns the stemmed words of a comment that carry meaning
+func commentWords(text string) []string {
+	var words []string
+	for _, word := range splitWords(text) {
+		if len(word) < 2 || stopWords[word] {
+			continue
+		}
+		words = append(words, stem(word))
+	}
+	return words
+}
+
+// splitWords splits text into lower case words, breaking identifiers at underscores and camel case
+func splitWords(text string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+	runes := []rune(text)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		// fooBar and HTTPServer break before the upper case letter that starts a word
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+// stem strips common English suffixes so "loads", "loading" and "loaded" match "load"
+func stem(word string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if len(word) > len(suffix)+2 && strings.HasSuffix(word, suffix) && !strings.HasSuffix(word, "ss") {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
+
+// matchesCode reports whether a comment word names something in the code, abbreviations such as
+// "init" for "initialize" match by prefix
+func matchesCode(word string, code map[string]bool) bool {
+	if code[word] {
+		return true
+	}
+	if len(word) < 3 {
+		return false
+	}
+	for token := range code {
+		if len(token) >= 3 && (strings.HasPrefix(token, word) || strings.HasPrefix(word, token)) {
+			return true
+		}
+	}
+	return false
+}
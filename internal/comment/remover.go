@@ -1,63 +1,188 @@
 package comment
 
 import (
+	"bytes"
 	"fmt"
-	"os"
 	"sort"
-	"unicode"
+	"strings"
 )
 
-func RemoveComments(filePath string, comments []Comment) error {
-	if len(comments) == 0 {
-		return nil
-	}
+// PlanRemoval reads a file and computes its content without the given comments, nothing is written.
+// The result is verified to have the same syntax tree as the original, otherwise a VerifyError is returned.
+func PlanRemoval(filePath string, comments []Comment) (FileEdit, error) {
+	return PlanEdit(filePath, comments, nil)
+}
 
-	input, err := os.ReadFile(filePath)
-	if err != nil {
-		return err
+// ApplyRemovals returns a copy of content with the comments cut out and the surrounding formatting cleaned up.
+// Comments that take up a whole line are removed together with their line.
+// Where a removal would break the syntax the gap is repaired, each repair is returned.
+func ApplyRemovals(filePath string, content []byte, comments []Comment) ([]byte, []Repair) {
+	rule := ruleForFile(filePath)
+	fillers := fillEmptyBodies(rule, filePath, content, comments)
+	expanded, wrapped := expandWrappers(rule, filePath, content, comments)
+	ranges := removalRanges(content, expanded, fillers, wrapped)
+	separateGlued(content, ranges)
+
+	var repairs []Repair
+	for _, r := range ranges {
+		if r.repair != "" {
+			repairs = append(repairs, Repair{Line: r.line, Description: r.repair})
+		}
 	}
 
-	sort.Slice(comments, func(i, j int) bool {
-		return comments[i].StartByte > comments[j].StartByte
-	})
+	output, cuts := cutRanges(content, ranges)
+	return cleanup(output, cuts), repairs
+}
 
-	output := input
-	for _, comment := range comments {
-		if isWholeLineComment(output, comment.StartByte) {
-			newStart := comment.StartByte
-			for newStart > 0 && output[newStart-1] != '\n' {
-				newStart--
-			}
+// removal is a byte range of the original content that gets cut
+type removal struct {
+	start uint32
+	end   uint32
+	// wholeLine is set when the range covers complete lines including their newline
+	wholeLine bool
+	// wrapper is set for a comment widened to its wrapper, such as {/* c */} in JSX
+	wrapper bool
+	// replacement is written in place of the range to keep the file valid, repair describes why
+	replacement string
+	repair      string
+	line        int
+}
+
+// removalRanges widens each comment to the bytes that should go with it, sorted by offset.
+// Comments with a filler are replaced by it in place, keeping their indentation.
+// Wrappers only take their line with them, the text around them may be significant.
+func removalRanges(content []byte, comments []Comment, fillers map[uint32]string, wrapped map[uint32]bool) []removal {
+	var ranges []removal
+	for _, c := range comments {
+		r := removal{start: c.StartByte, end: c.EndByte, line: c.Line, wrapper: wrapped[c.StartByte]}
 
-			if comment.EndByte < uint32(len(output)) && output[comment.EndByte] == '\n' {
-				comment.EndByte++
+		// Some grammars include the \r of a CRLF line ending in line comments
+		for r.end > r.start && content[r.end-1] == '\r' {
+			r.end--
+		}
+
+		if filler, ok := fillers[c.StartByte]; ok {
+			r.replacement = filler
+			r.repair = fmt.Sprintf("inserted %s into the emptied block", filler)
+		} else if isWholeLineComment(content, c.StartByte, c.EndByte) {
+			for r.start > uint32(bomLength(content)) && content[r.start-1] != '\n' {
+				r.start--
+			}
+			for r.end < uint32(len(content)) && content[r.end] != '\n' {
+				r.end++
+			}
+			if r.end < uint32(len(content)) {
+				r.end++
 			}
-			comment.StartByte = newStart
+			r.wholeLine = true
+		} else if r.wrapper {
+			// JSX keeps the spaces around {/* c */} on the same line as text
+		} else if onlySpaceBefore(content, r.start) || isBlankByte(content, int(r.start)-1) && isBlankByte(content, int(r.end)) {
+			// Keep the indentation or the space before the comment, the space after it goes
+			for isBlankByte(content, int(r.end)) {
+				r.end++
+			}
+		} else if isBlankByte(content, int(r.start)-1) && isClosing(content, int(r.end)) {
+			// "f(a /* c */)" becomes "f(a)"
+			for isBlankByte(content, int(r.start)-1) {
+				r.start--
+			}
+		} else if onlySpaceAfter(content, r.end) && continuesLine(content, r.start) {
+			r.repair = "kept the space after the trailing backslash so the next line is not joined"
 		}
-		output = append(output[:comment.StartByte], output[comment.EndByte:]...)
 
+		ranges = append(ranges, r)
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start < ranges[j].start
+	})
+	return ranges
+}
+
+// cutRanges removes the ranges from content and returns where each cut ended up in the output
+func cutRanges(content []byte, ranges []removal) ([]byte, []cut) {
+	output := make([]byte, 0, len(content))
+	var cuts []cut
+
+	pos := uint32(0)
+	for _, r := range ranges {
+		if r.end <= pos {
+			continue
+		}
+		start := max(r.start, pos)
+		output = append(output, content[pos:start]...)
+		output = append(output, r.replacement...)
+		cuts = append(cuts, cut{offset: len(output), wholeLine: r.wholeLine && start == r.start})
+		pos = r.end
 	}
+	output = append(output, content[pos:]...)
+	return output, cuts
+}
 
-	tmpFile := filePath + ".tmp"
-	if err := os.WriteFile(tmpFile, output, 0644); err != nil {
-		return fmt.Errorf("failed to write tmp file: %w", err)
+// RemoveComments removes the comments from a file on disk
+func RemoveComments(filePath string, comments []Comment) error {
+	if len(comments) == 0 {
+		return nil
 	}
-	if err := os.Rename(tmpFile, filePath); err != nil {
-		return fmt.Errorf("failed to rename tmp file: %w", err)
+
+	edit, err := PlanRemoval(filePath, comments)
+	if err != nil {
+		return err
 	}
+	return WriteEdit(edit)
+}
 
-	return nil
+// isWholeLineComment reports whether a comment is the only thing on its lines
+func isWholeLineComment(content []byte, startPos, endPos uint32) bool {
+	return onlySpaceBefore(content, startPos) && onlySpaceAfter(content, endPos)
 }
 
-func isWholeLineComment(content []byte, startPos uint32) bool {
-	for i := int(startPos) - 1; i >= 0; i-- {
+// onlySpaceAfter reports whether only whitespace follows pos on its line
+func onlySpaceAfter(content []byte, pos uint32) bool {
+	for i := int(pos); i < len(content); i++ {
 		b := content[i]
 		if b == '\n' {
 			return true
 		}
-		if !unicode.IsSpace(rune(b)) {
+		if !isSpace(b) {
 			return false
 		}
 	}
 	return true
 }
+
+// onlySpaceBefore reports whether only indentation precedes pos on its line
+func onlySpaceBefore(content []byte, pos uint32) bool {
+	for i := int(pos) - 1; i >= bomLength(content); i-- {
+		b := content[i]
+		if b == '\n' {
+			return true
+		}
+		if !isSpace(b) {
+			return false
+		}
+	}
+	return true
+}
+
+// bomLength returns the length of the UTF-8 byte order mark content starts with, if any
+func bomLength(content []byte) int {
+	if bytes.HasPrefix(content, utf8BOM) {
+		return len(utf8BOM)
+	}
+	return 0
+}
+
+// isBlankByte reports whether content has a space or tab at i
+func isBlankByte(content []byte, i int) bool {
+	return i >= 0 && i < len(content) && (content[i] == ' ' || content[i] == '\t')
+}
+
+func isClosing(content []byte, i int) bool {
+	return i < len(content) && strings.IndexByte(")]},;", content[i]) >= 0
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\f' || b == '\v'
+}
@@ -0,0 +1,148 @@
+package comment
+
+import (
+	"bytes"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// glueOpeners are character pairs that start a comment when a removal joins them
+var glueOpeners = []string{"/*", "//", "(*", "{-", "--", "<!"}
+
+// fillEmptyBodies finds the comments that are the only content of a body that may not be empty.
+// It returns the filler statement to put in place of each of them, keyed by comment start.
+func fillEmptyBodies(rule cleanupRule, filePath string, content []byte, comments []Comment) map[uint32]string {
+	if len(rule.bodies) == 0 || len(comments) == 0 {
+		return nil
+	}
+
+	tree, err := ParseFile(filePath, content)
+	if err != nil {
+		return nil
+	}
+	defer tree.Close()
+
+	removed := make(map[uint32]bool)
+	for _, c := range comments {
+		removed[c.StartByte] = true
+	}
+
+	fillers := make(map[uint32]string)
+	var walk func(node *sitter.Node)
+	walk = func(node *sitter.Node) {
+		if isBody(rule, node) {
+			if node.NamedChildCount() > 0 && onlyRemovedComments(node, removed) {
+				fillers[node.NamedChild(0).StartByte()] = rule.filler
+			} else if node.NamedChildCount() == 0 {
+				// Indentation based grammars end an empty body before the comments that were meant for it
+				if c, ok := commentAfterBody(content, node, comments); ok {
+					fillers[c.StartByte] = rule.filler
+				}
+			}
+		}
+		for i := 0; i < int(node.ChildCount()); i++ {
+			walk(node.Child(i))
+		}
+	}
+	walk(tree.RootNode())
+	return fillers
+}
+
+// commentAfterBody returns the first comment following an empty body that is indented deeper than its header
+func commentAfterBody(content []byte, body *sitter.Node, comments []Comment) (Comment, bool) {
+	header := indentation(content, body.StartByte())
+	for _, c := range comments {
+		if c.StartByte < body.EndByte() {
+			continue
+		}
+		lineStart := lineStart(content, c.StartByte)
+		if lineStart < int(body.EndByte()) || len(bytes.TrimSpace(content[body.EndByte():lineStart])) > 0 || !onlySpaceBefore(content, c.StartByte) {
+			return Comment{}, false
+		}
+		return c, indentation(content, c.StartByte) > header
+	}
+	return Comment{}, false
+}
+
+// separateGlued puts a space in place of removals that would join the tokens around them into one,
+// adjacent removals are treated as a single one. Removed wrappers are never replaced, a space in JSX
+// would be a new text child.
+func separateGlued(content []byte, ranges []removal) {
+	for i := 0; i < len(ranges); {
+		j := i
+		wrapper := ranges[i].wrapper
+		for j+1 < len(ranges) && ranges[j+1].start <= ranges[j].end {
+			j++
+			wrapper = wrapper || ranges[j].wrapper
+		}
+		start, end := ranges[i].start, ranges[j].end
+		if !ranges[i].wholeLine && !wrapper && ranges[j].replacement == "" && start > 0 && int(end) < len(content) &&
+			glues(content[start-1], content[end]) {
+			ranges[j].replacement = " "
+			ranges[j].repair = "inserted a space to keep the surrounding tokens apart"
+		}
+		i = j + 1
+	}
+}
+
+// glues reports whether two characters that end up next to each other read as a different token
+func glues(before, after byte) bool {
+	if isWordByte(before) && isWordByte(after) {
+		return true
+	}
+	const operators = "+-*/%<>=!&|^~.:?@#"
+	// Between > and < a tag ends and the next one starts, a space there would be new JSX text
+	if before == '>' && after == '<' {
+		return false
+	}
+	if strings.IndexByte(operators, before) >= 0 && strings.IndexByte(operators, after) >= 0 {
+		return true
+	}
+	for _, opener := range glueOpeners {
+		if before == opener[0] && after == opener[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// continuesLine reports whether the text before a trailing comment ends in a backslash followed by blanks,
+// trimming those blanks would join the next line
+func continuesLine(content []byte, start uint32) bool {
+	i := int(start) - 1
+	if !isBlankByte(content, i) {
+		return false
+	}
+	for isBlankByte(content, i) {
+		i--
+	}
+	return i >= 0 && content[i] == '\\'
+}
+
+func isBody(rule cleanupRule, node *sitter.Node) bool {
+	for _, body := range rule.bodies {
+		if node.Type() == body {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b == '$' || b >= 0x80 || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9'
+}
+
+func lineStart(content []byte, pos uint32) int {
+	return bytes.LastIndexByte(content[:pos], '\n') + 1
+}
+
+// indentation returns the width of the leading whitespace on the line containing pos
+func indentation(content []byte, pos uint32) int {
+	start := lineStart(content, pos)
+	width := 0
+	for start+width < len(content) && isBlankByte(content, start+width) {
+		width++
+	}
+	return width
+}
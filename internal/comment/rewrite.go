@@ -0,0 +1,142 @@
+package comment
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Rewrite replaces the text of a comment in place, such as a comment with a secret redacted
+type Rewrite struct {
+	Comment Comment
+	// Text is the new text of the whole comment, delimiters included
+	Text string
+}
+
+// PlanEdit reads a file and computes its content with the rewrites applied and the removals cut out,
+// nothing is written. The result is verified like PlanRemoval.
+func PlanEdit(filePath string, removals []Comment, rewrites []Rewrite) (FileEdit, error) {
+	input, err := os.ReadFile(filePath)
+	if err != nil {
+		return FileEdit{}, err
+	}
+	scanned := make([]Comment, 0, len(removals)+len(rewrites))
+	scanned = append(scanned, removals...)
+	for _, r := range rewrites {
+		scanned = append(scanned, r.Comment)
+	}
+	if err := CheckScanned(filePath, input, scanned); err != nil {
+		return FileEdit{}, err
+	}
+
+	rewritten := ApplyRewrites(input, rewrites)
+	updated, repairs := ApplyRemovals(filePath, rewritten, ShiftPastRewrites(removals, rewrites))
+	if err := Verify(filePath, input, updated); err != nil {
+		return FileEdit{}, err
+	}
+
+	return FileEdit{
+		Path:     filePath,
+		Original: input,
+		Updated:  updated,
+		Comments: removals,
+		Rewrites: rewrites,
+		Repairs:  repairs,
+	}, nil
+}
+
+// ApplyRewrites returns a copy of content with the text of each rewritten comment replaced
+func ApplyRewrites(content []byte, rewrites []Rewrite) []byte {
+	sorted := make([]Rewrite, len(rewrites))
+	copy(sorted, rewrites)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Comment.StartByte < sorted[j].Comment.StartByte
+	})
+
+	output := make([]byte, 0, len(content))
+	pos := uint32(0)
+	for _, r := range sorted {
+		if r.Comment.StartByte < pos {
+			continue
+		}
+		output = append(output, content[pos:r.Comment.StartByte]...)
+		output = append(output, r.Text...)
+		pos = r.Comment.EndByte
+	}
+	return append(output, content[pos:]...)
+}
+
+// ShiftPastRewrites moves comment offsets to account for rewrites before them that changed length
+func ShiftPastRewrites(comments []Comment, rewrites []Rewrite) []Comment {
+	shifted := make([]Comment, len(comments))
+	for i, c := range comments {
+		if len(rewrites) > 0 {
+			c.FileHash = ""
+		}
+		delta := 0
+		for _, r := range rewrites {
+			if r.Comment.StartByte < c.StartByte {
+				delta += len(r.Text) - int(r.Comment.EndByte-r.Comment.StartByte)
+			}
+		}
+		c.StartByte = uint32(int(c.StartByte) + delta)
+		c.EndByte = uint32(int(c.EndByte) + delta)
+		shifted[i] = c
+	}
+	return shifted
+}
+
+// Rewriter decides which comments to remove and which to rewrite in place. Comments the remove filter
+// keeps are removed as usual, the text of the others, keep marked ones included, goes through rewrite.
+type Rewriter struct {
+	remove  func(*Comment) bool
+	rewrite func(string) string
+
+	mu       sync.Mutex
+	rewrites map[string]Rewrite
+}
+
+// NewRewriter returns a rewriter, a nil remove filter removes every comment that is not keep marked
+func NewRewriter(remove func(*Comment) bool, rewrite func(string) string) *Rewriter {
+	return &Rewriter{remove: remove, rewrite: rewrite, rewrites: make(map[string]Rewrite)}
+}
+
+// Filter keeps the comments to remove and the comments rewrite changes, it is safe for concurrent use
+func (r *Rewriter) Filter(c *Comment) bool {
+	if !strings.Contains(c.Text, KeepMarker) && (r.remove == nil || r.remove(c)) {
+		return true
+	}
+	text := r.rewrite(c.Text)
+	if text == c.Text {
+		return false
+	}
+
+	r.mu.Lock()
+	r.rewrites[rewriteKey(*c)] = Rewrite{Comment: *c, Text: text}
+	r.mu.Unlock()
+	return true
+}
+
+// Split separates the filtered comments into removals and rewrites
+func (r *Rewriter) Split(comments []Comment) ([]Comment, []Rewrite) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var removals []Comment
+	var rewrites []Rewrite
+	for _, c := range comments {
+		if rewrite, ok := r.rewrites[rewriteKey(c)]; ok {
+			rewrite.Comment = c
+			rewrites = append(rewrites, rewrite)
+		} else {
+			removals = append(removals, c)
+		}
+	}
+	return removals, rewrites
+}
+
+func rewriteKey(c Comment) string {
+	return fmt.Sprintf("%s:%d", c.FilePath, c.StartByte)
+}
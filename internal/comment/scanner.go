@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 	"walle/internal/languages"
 	"walle/internal/source"
@@ -40,25 +41,19 @@ var commentQueries = []string{
 }
 
 func (s *TreeSitterScanner) Scan(file source.File) ([]Comment, error) {
-	parser := sitter.NewParser()
-	parser.SetLanguage(s.Language)
-
+	parser := getParser(s.Language)
 	tree, err := parser.ParseCtx(context.Background(), nil, file.Content)
+	putParser(s.Language, parser)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse file %s: %w", file.Path, err)
 	}
 	defer tree.Close()
 
-	var comments []Comment
-
-	// Try each query pattern and collect all comments
-	for _, queryMessage := range commentQueries {
-		query, err := sitter.NewQuery([]byte(queryMessage), s.Language)
-		if err != nil {
-			// This query pattern is not supported by this grammar, skip it
-			continue
-		}
+	var all []Comment
+	fileHash := contentHash(file.Content)
 
+	// Collect the comments of every query pattern the grammar supports
+	for _, query := range commentQueriesFor(s.Language) {
 		queryCursor := sitter.NewQueryCursor()
 		queryCursor.Exec(query, tree.RootNode())
 
@@ -70,32 +65,39 @@ func (s *TreeSitterScanner) Scan(file source.File) ([]Comment, error) {
 
 			for _, capture := range match.Captures {
 				node := capture.Node
-				line := int(node.StartPoint().Row) + 1
-
-				if file.Status == source.StatusAdded || file.Status == source.StatusUntracked {
-					comments = append(comments, Comment{
-						FilePath:  file.Path,
-						Text:      node.Content(file.Content),
-						Line:      line,
-						StartByte: node.StartByte(),
-						EndByte:   node.EndByte(),
-					})
-				} else if isLineInDiffRanges(line, file.DiffRanges) {
-					comments = append(comments, Comment{
-						FilePath:  file.Path,
-						Text:      node.Content(file.Content),
-						Line:      line,
-						StartByte: node.StartByte(),
-						EndByte:   node.EndByte(),
-					})
-				}
+				text := node.Content(file.Content)
+				kind := Classify(node.Type(), text)
+
+				all = append(all, Comment{
+					FilePath:   file.Path,
+					Text:       text,
+					Line:       int(node.StartPoint().Row) + 1,
+					StartByte:  node.StartByte(),
+					EndByte:    node.EndByte(),
+					Kind:       kind,
+					Symbol:     enclosingSymbol(node, file.Content),
+					FileHash:   fileHash,
+					Redundancy: redundancy(node, file.Content, kind),
+				})
 			}
 		}
 
 		queryCursor.Close()
-		query.Close()
 	}
 
+	// IDs are assigned over every comment in the file so they don't depend on the diff
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].StartByte < all[j].StartByte
+	})
+	assignIDs(file.Path, all)
+
+	var comments []Comment
+	for _, c := range all {
+		if file.Status != source.StatusAdded && file.Status != source.StatusUntracked && !isLineInDiffRanges(c.Line, file.DiffRanges) {
+			continue
+		}
+		comments = append(comments, c)
+	}
 	return comments, nil
 }
 
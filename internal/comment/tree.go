@@ -0,0 +1,48 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"walle/internal/languages"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// ParseFile parses content with the grammar for the file's extension
+func ParseFile(filePath string, content []byte) (*sitter.Tree, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	lang := languages.GetLanguageForExtension(ext)
+	if lang == nil {
+		return nil, fmt.Errorf("unsupported file type: %s", ext)
+	}
+
+	parser := getParser(lang)
+	defer putParser(lang, parser)
+	return parser.ParseCtx(context.Background(), nil, content)
+}
+
+// IsCommentNode reports whether a node is a comment in any of the supported grammars
+func IsCommentNode(node *sitter.Node) bool {
+	return strings.Contains(node.Type(), "comment")
+}
+
+// DescendantForByteRange returns the smallest node that spans the byte range
+func DescendantForByteRange(node *sitter.Node, start, end uint32) *sitter.Node {
+	for node.StartByte() != start || node.EndByte() != end {
+		var next *sitter.Node
+		for i := 0; i < int(node.ChildCount()); i++ {
+			child := node.Child(i)
+			if child.StartByte() <= start && child.EndByte() >= end {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return node
+}
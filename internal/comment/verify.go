@@ -0,0 +1,162 @@
+package comment
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// VerifyError reports a removal that would change the meaning or the validity of a file
+type VerifyError struct {
+	Path   string
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("not removing comments from %s: %s", e.Path, e.Reason)
+}
+
+// Verify re-parses the updated content and checks that, ignoring comments, it has the same syntax tree
+// as the original. Glued tokens such as a/*x*/b becoming ab, or new parse errors, are rejected.
+func Verify(filePath string, original, updated []byte) error {
+	rule := ruleForFile(filePath)
+
+	before, beforeErrors, err := signature(filePath, original, rule)
+	if err != nil {
+		return err
+	}
+	after, afterErrors, err := signature(filePath, updated, rule)
+	if err != nil {
+		return err
+	}
+
+	if afterErrors > beforeErrors {
+		return &VerifyError{Path: filePath, Reason: fmt.Sprintf("removal introduces %d syntax errors", afterErrors-beforeErrors)}
+	}
+
+	if !slices.Equal(before, after) {
+		i := 0
+		for i < len(before) && i < len(after) && before[i] == after[i] {
+			i++
+		}
+		return &VerifyError{Path: filePath, Reason: fmt.Sprintf("syntax tree changes at %s", describeToken(before, after, i))}
+	}
+	return nil
+}
+
+// signature flattens a syntax tree without its comments into tokens, and counts its error nodes
+func signature(filePath string, content []byte, rule cleanupRule) ([]string, int, error) {
+	tree, err := ParseFile(filePath, content)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tree.Close()
+
+	var tokens []string
+	errors := 0
+	var walk func(node *sitter.Node)
+	walk = func(node *sitter.Node) {
+		if node.IsError() || node.IsMissing() {
+			errors++
+		}
+		if isIgnored(rule, node) || (isWrapper(rule, node) && onlyComments(node)) {
+			return
+		}
+		if node.Type() == "jsx_text" {
+			// JSX text is part of the output, its whitespace is compared the way JSX renders it
+			text := jsxText(node.Content(content))
+			if n := len(tokens); n > 0 && strings.HasPrefix(tokens[n-1], "jsx_text:") {
+				tokens[n-1] += text
+			} else if text != "" {
+				tokens = append(tokens, "jsx_text:"+text)
+			}
+			return
+		}
+
+		// Nodes that only held comments compare equal to nodes that never had children
+		if node.ChildCount() == 0 || onlyIgnoredChildren(rule, node) {
+			text := ""
+			if node.ChildCount() == 0 {
+				text = strings.Join(strings.Fields(node.Content(content)), " ")
+			}
+			if text == "" && node.IsNamed() {
+				// Whitespace only text nodes come and go with removed lines
+				return
+			}
+			token := node.Type() + ":" + text
+			// Text split around a removed comment is joined again
+			if n := len(tokens); n > 0 && strings.HasPrefix(tokens[n-1], node.Type()+":") && node.IsNamed() {
+				tokens[n-1] += " " + text
+				return
+			}
+			tokens = append(tokens, token)
+			return
+		}
+
+		tokens = append(tokens, "("+node.Type())
+		for i := 0; i < int(node.ChildCount()); i++ {
+			walk(node.Child(i))
+		}
+		tokens = append(tokens, ")")
+	}
+	walk(tree.RootNode())
+
+	return tokens, errors, nil
+}
+
+// jsxText returns text the way JSX renders it: lines are trimmed where they break, lines left empty
+// are dropped and the rest joined by a space. Whitespace without a line break is kept as is.
+func jsxText(text string) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		return text
+	}
+	var kept []string
+	for i, line := range lines {
+		if i > 0 {
+			line = strings.TrimLeft(line, " \t\r")
+		}
+		if i < len(lines)-1 {
+			line = strings.TrimRight(line, " \t\r")
+		}
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// isIgnored reports whether a node is a comment or a filler that a repair may have inserted
+func isIgnored(rule cleanupRule, node *sitter.Node) bool {
+	return IsCommentNode(node) || rule.fillerNode != "" && node.Type() == rule.fillerNode
+}
+
+func onlyIgnoredChildren(rule cleanupRule, node *sitter.Node) bool {
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if !isIgnored(rule, node.Child(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+func onlyComments(node *sitter.Node) bool {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		if !IsCommentNode(node.NamedChild(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+func describeToken(before, after []string, i int) string {
+	token := func(tokens []string) string {
+		if i >= len(tokens) {
+			return "end of file"
+		}
+		return fmt.Sprintf("%q", tokens[i])
+	}
+	return fmt.Sprintf("%s (was %s)", token(after), token(before))
+}
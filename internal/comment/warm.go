@@ -0,0 +1,58 @@
+package comment
+
+import (
+	"sync"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Parsers are pooled and comment queries compiled once per language, so a long running process such as
+// walle serve pays the grammar setup only on the first file of a language
+
+var (
+	parserPools sync.Map
+	queryCache  sync.Map
+	queryMu     sync.Mutex
+)
+
+// getParser returns a parser for lang, hand it back with putParser
+func getParser(lang *sitter.Language) *sitter.Parser {
+	pool, _ := parserPools.LoadOrStore(lang, &sync.Pool{})
+	if parser, ok := pool.(*sync.Pool).Get().(*sitter.Parser); ok {
+		return parser
+	}
+	parser := sitter.NewParser()
+	parser.SetLanguage(lang)
+	return parser
+}
+
+func putParser(lang *sitter.Language, parser *sitter.Parser) {
+	parser.Reset()
+	pool, _ := parserPools.LoadOrStore(lang, &sync.Pool{})
+	pool.(*sync.Pool).Put(parser)
+}
+
+// commentQueriesFor returns the comment queries the grammar of lang supports, compiled once.
+// Compiled queries are never closed, cursors can share them.
+func commentQueriesFor(lang *sitter.Language) []*sitter.Query {
+	if queries, ok := queryCache.Load(lang); ok {
+		return queries.([]*sitter.Query)
+	}
+	queryMu.Lock()
+	defer queryMu.Unlock()
+	if queries, ok := queryCache.Load(lang); ok {
+		return queries.([]*sitter.Query)
+	}
+
+	var queries []*sitter.Query
+	for _, pattern := range commentQueries {
+		query, err := sitter.NewQuery([]byte(pattern), lang)
+		if err != nil {
+			// This query pattern is not supported by this grammar, skip it
+			continue
+		}
+		queries = append(queries, query)
+	}
+	queryCache.Store(lang, queries)
+	return queries
+}
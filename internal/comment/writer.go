@@ -0,0 +1,114 @@
+package comment
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrModified is returned when a file changed on disk after it was scanned
+var ErrModified = errors.New("file changed since it was scanned")
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// WriteEdit replaces the file on disk with the updated content of the edit.
+// Symlinks are followed and the file keeps its mode and owner. The new content is synced to disk
+// before it atomically takes the place of the old, nothing is written when the file no longer
+// holds the original content of the edit.
+func WriteEdit(edit FileEdit) error {
+	target, err := filepath.EvalSymlinks(edit.Path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", edit.Path, err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+
+	current, err := os.ReadFile(target)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, edit.Original) {
+		return fmt.Errorf("%s: %w", edit.Path, ErrModified)
+	}
+
+	return writeAtomic(target, edit.Updated, info)
+}
+
+// writeAtomic writes content to a temporary file next to path and renames it over path
+func writeAtomic(path string, content []byte, info os.FileInfo) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".walle-*")
+	if err != nil {
+		return fmt.Errorf("failed to create tmp file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(content); err != nil {
+		return fmt.Errorf("failed to write tmp file: %w", err)
+	}
+	if err = tmp.Chmod(info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)); err != nil {
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if err = keepOwner(tmp, info); err != nil {
+		return fmt.Errorf("failed to keep file owner: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync tmp file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close tmp file: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to rename tmp file: %w", err)
+	}
+
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// syncDir flushes the rename to disk, not every platform supports syncing a directory so errors are ignored
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	d.Sync()
+}
+
+// CheckScanned returns ErrModified when content is not what the comments were scanned from, and an
+// error for comments that don't fit in content, whose offsets can't be trusted without a hash
+func CheckScanned(filePath string, content []byte, comments []Comment) error {
+	hash := ""
+	for _, c := range comments {
+		if c.StartByte > c.EndByte || int(c.EndByte) > len(content) {
+			return fmt.Errorf("%s: comment at bytes %d-%d is outside the %d bytes of content", filePath, c.StartByte, c.EndByte, len(content))
+		}
+		if c.FileHash == "" {
+			continue
+		}
+		if hash == "" {
+			hash = contentHash(content)
+		}
+		if c.FileHash != hash {
+			return fmt.Errorf("%s: %w", filePath, ErrModified)
+		}
+	}
+	return nil
+}
+
+// contentHash returns the hex encoded sha256 of content
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,307 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"walle/internal/comment"
+)
+
+// group is a run of comments formatted together, consecutive line comments form one group
+type group struct {
+	comments []comment.Comment
+	// marker is the line comment marker, empty for block comments
+	marker string
+	indent string
+	// trailing is set for a comment that follows code on its line
+	trailing bool
+	// whole is set for comments on lines of their own
+	whole bool
+}
+
+// change replaces a byte range of the original content
+type change struct {
+	start, end uint32
+	text       string
+}
+
+// File formats the given comments of a file and returns the edit, comments that would not change are left out.
+// Directives are never touched. The result is verified to have the same syntax tree as the original.
+func File(filePath string, content []byte, comments []comment.Comment, opts Options) (comment.FileEdit, error) {
+	syn, ok := syntaxFor(filePath)
+	if !ok {
+		return comment.FileEdit{}, fmt.Errorf("unsupported file type: %s", filePath)
+	}
+	if err := comment.CheckScanned(filePath, content, comments); err != nil {
+		return comment.FileEdit{}, err
+	}
+
+	newline := "\n"
+	if bytes.Contains(content, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+
+	var changes []change
+	var changed []comment.Comment
+	for _, g := range groups(content, syn, comments) {
+		text := string(content[g.comments[0].StartByte:g.comments[len(g.comments)-1].EndByte])
+		formatted, ok := formatGroup(content, syn, g, opts, newline)
+		if !ok {
+			continue
+		}
+
+		start, end := g.comments[0].StartByte, g.comments[len(g.comments)-1].EndByte
+		if g.trailing && opts.MoveTrailing {
+			// The comment goes on its own line above the code, at the indentation of the code
+			codeEnd := start
+			for codeEnd > 0 && (content[codeEnd-1] == ' ' || content[codeEnd-1] == '\t') {
+				codeEnd--
+			}
+			lineStart := uint32(bytes.LastIndexByte(content[:start], '\n') + 1)
+			indent := leadingSpace(content[lineStart:])
+			formatted, ok = formatGroup(content, syn, group{comments: g.comments, marker: g.marker, indent: indent, whole: true}, opts, newline)
+			if !ok {
+				continue
+			}
+			changes = append(changes,
+				change{start: lineStart, end: lineStart, text: indent + formatted + newline},
+				change{start: codeEnd, end: end, text: ""})
+			changed = append(changed, g.comments...)
+			continue
+		}
+
+		if formatted == text {
+			continue
+		}
+		changes = append(changes, change{start: start, end: end, text: formatted})
+		changed = append(changed, g.comments...)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].start < changes[j].start
+	})
+	var sb bytes.Buffer
+	pos := uint32(0)
+	for _, c := range changes {
+		sb.Write(content[pos:c.start])
+		sb.WriteString(c.text)
+		pos = c.end
+	}
+	sb.Write(content[pos:])
+	updated := sb.Bytes()
+
+	if len(changed) > 0 {
+		if err := comment.Verify(filePath, content, updated); err != nil {
+			return comment.FileEdit{}, err
+		}
+	}
+	return comment.FileEdit{Path: filePath, Original: content, Updated: updated, Comments: changed}, nil
+}
+
+// groups sorts the comments and joins consecutive line comments with the same marker and indentation
+func groups(content []byte, syn syntax, comments []comment.Comment) []group {
+	sorted := make([]comment.Comment, 0, len(comments))
+	for _, c := range comments {
+		// Some grammars include the line ending in line comments
+		for c.EndByte > c.StartByte && (content[c.EndByte-1] == '\n' || content[c.EndByte-1] == '\r') {
+			c.EndByte--
+		}
+		c.Text = string(content[c.StartByte:c.EndByte])
+		sorted = append(sorted, c)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartByte < sorted[j].StartByte
+	})
+
+	var result []group
+	for _, c := range sorted {
+		lineStart := uint32(bytes.LastIndexByte(content[:c.StartByte], '\n') + 1)
+		before := content[lineStart:c.StartByte]
+		after := content[c.EndByte:lineEnd(content, c.EndByte)]
+		marker, isLine := syn.lineMarker(c.Text)
+
+		g := group{
+			comments: []comment.Comment{c},
+			marker:   marker,
+			indent:   string(before),
+			whole:    len(bytes.TrimSpace(before)) == 0 && len(bytes.TrimSpace(after)) == 0,
+			trailing: len(bytes.TrimSpace(before)) > 0 && len(bytes.TrimSpace(after)) == 0,
+		}
+		if g.trailing {
+			g.indent = ""
+		}
+
+		if n := len(result); n > 0 && isLine && g.whole {
+			previous := &result[n-1]
+			last := previous.comments[len(previous.comments)-1]
+			if previous.whole && previous.marker == marker && previous.indent == g.indent &&
+				last.Kind == c.Kind && c.Line == last.Line+1 {
+				previous.comments = append(previous.comments, c)
+				continue
+			}
+		}
+		result = append(result, g)
+	}
+	return result
+}
+
+// formatGroup returns the formatted text of a group, the first line without indentation
+func formatGroup(content []byte, syn syntax, g group, opts Options, newline string) (string, bool) {
+	for _, c := range g.comments {
+		if c.Kind == comment.KindDirective {
+			return "", false
+		}
+	}
+
+	first := g.comments[0]
+	last := g.comments[len(g.comments)-1]
+	code := string(content[lineStartOf(content, first.StartByte):first.StartByte]) + "\n" + followingCode(content, last.EndByte)
+
+	// Doc comments keep their style, tools read them by their marker
+	style := opts.Style
+	if first.Kind == comment.KindDoc {
+		style = StyleKeep
+	}
+
+	var lines []string
+	var open, close string
+	isBlock := g.marker == ""
+	// Languages without the other style keep the style they have
+	if style == StyleLine && syn.line == "" || style == StyleBlock && syn.blockOpen == "" {
+		style = StyleKeep
+	}
+	rewrite := opts.Wrap > 0 && g.whole || style == StyleLine && isBlock || style == StyleBlock && !isBlock
+	if isBlock {
+		var ok bool
+		open, close, ok = syn.blockMarkers(first.Text)
+		if !ok {
+			return "", false
+		}
+		if !rewrite {
+			// Keep the layout of comments that keep their style
+			return tidyBlock(first, open, close, code, opts), true
+		}
+		lines = decodeBlock(first.Text, open, close)
+	} else if !rewrite {
+		return tidyLines(content, g, code, opts), true
+	} else {
+		texts := make([]string, len(g.comments))
+		for i, c := range g.comments {
+			texts[i] = c.Text
+		}
+		lines = decodeLines(texts, g.marker)
+	}
+
+	if opts.Capitalize {
+		lines = capitalize(lines, code)
+	}
+	if opts.Wrap > 0 && g.whole {
+		width := opts.Wrap - len(g.indent) - max(len(g.marker), len(open)) - 1
+		lines = reflow(lines, max(width, 20))
+	}
+
+	switch {
+	case style == StyleLine && isBlock && (g.whole || g.trailing && len(lines) == 1):
+		return encodeLines(lines, syn.line, g.indent, newline), true
+	case style == StyleBlock && !isBlock:
+		if g.trailing && len(lines) > 1 || strings.Contains(strings.Join(lines, "\n"), syn.blockClose) {
+			return "", false
+		}
+		return encodeBlock(lines, syn.blockOpen, syn.blockClose, g.indent, newline), true
+	case isBlock:
+		if !g.whole && len(lines) > 1 {
+			return "", false
+		}
+		return encodeBlock(lines, open, close, g.indent, newline), true
+	}
+	return encodeLines(lines, g.marker, g.indent, newline), true
+}
+
+// tidyBlock normalizes the spacing inside the markers of a block comment and capitalizes it,
+// the lines in between are left alone
+func tidyBlock(c comment.Comment, open, close, code string, opts Options) string {
+	inner := c.Text[len(open) : len(c.Text)-len(close)]
+	if strings.TrimSpace(inner) == "" {
+		return c.Text
+	}
+	inner = tidyBody(inner, code, opts.Capitalize)
+	if last := inner[len(inner)-1]; !isSpaceByte(last) && !isDecoration(last) {
+		inner += " "
+	}
+	return open + inner + close
+}
+
+// tidyLines normalizes the spacing after the marker of each comment in a line comment group,
+// and capitalizes the first one
+func tidyLines(content []byte, g group, code string, opts Options) string {
+	var sb strings.Builder
+	pos := g.comments[0].StartByte
+	for i, c := range g.comments {
+		sb.Write(content[pos:c.StartByte])
+		sb.WriteString(g.marker + tidyBody(c.Text[len(g.marker):], code, opts.Capitalize && i == 0))
+		pos = c.EndByte
+	}
+	return sb.String()
+}
+
+// tidyBody puts a space between the marker and the text of a comment, decorations such as ----- are left alone
+func tidyBody(body, code string, capitalizeFirst bool) string {
+	if body == "" {
+		return body
+	}
+	if !isSpaceByte(body[0]) && !isDecoration(body[0]) {
+		body = " " + body
+	}
+	if capitalizeFirst {
+		leading := body[:len(body)-len(strings.TrimLeft(body, " \t\r\n"))]
+		body = leading + capitalize([]string{body[len(leading):]}, code)[0]
+	}
+	return body
+}
+
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+func isDecoration(b byte) bool {
+	return strings.IndexByte("*-=#/!<>+~_", b) >= 0
+}
+
+// followingCode returns the next line after pos that is not blank, used to recognize identifiers
+func followingCode(content []byte, pos uint32) string {
+	rest := content[pos:]
+	for len(rest) > 0 {
+		i := bytes.IndexByte(rest, '\n')
+		line := rest
+		if i >= 0 {
+			line, rest = rest[:i], rest[i+1:]
+		} else {
+			rest = nil
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			return string(line)
+		}
+	}
+	return ""
+}
+
+// lineEnd returns the offset of the line ending after pos, before any \r
+func lineEnd(content []byte, pos uint32) uint32 {
+	end := uint32(len(content))
+	if i := bytes.IndexByte(content[pos:], '\n'); i >= 0 {
+		end = pos + uint32(i)
+	}
+	if end > pos && content[end-1] == '\r' {
+		end--
+	}
+	return end
+}
+
+func lineStartOf(content []byte, pos uint32) uint32 {
+	return uint32(bytes.LastIndexByte(content[:pos], '\n') + 1)
+}
+
+func leadingSpace(line []byte) string {
+	return string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+}
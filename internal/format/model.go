@@ -0,0 +1,31 @@
+package format
+
+import "fmt"
+
+// Style is the comment style format converts to
+type Style string
+
+const (
+	// StyleKeep leaves line comments as line comments and block comments as block comments
+	StyleKeep  Style = ""
+	StyleLine  Style = "line"
+	StyleBlock Style = "block"
+)
+
+// Options selects the changes format makes, spacing after the comment marker is always normalized
+type Options struct {
+	Style        Style
+	MoveTrailing bool
+	// Wrap reflows comment text to this many columns, 0 leaves lines as they are
+	Wrap       int
+	Capitalize bool
+}
+
+// ParseStyle converts a --style value into a Style
+func ParseStyle(name string) (Style, error) {
+	switch Style(name) {
+	case StyleKeep, StyleLine, StyleBlock:
+		return Style(name), nil
+	}
+	return "", fmt.Errorf("unknown comment style %q (expected line or block)", name)
+}
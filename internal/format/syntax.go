@@ -0,0 +1,85 @@
+package format
+
+import (
+	"path/filepath"
+	"strings"
+	"walle/internal/languages"
+)
+
+// syntax holds the comment markers of a language, an empty marker means the style does not exist
+type syntax struct {
+	line       string
+	blockOpen  string
+	blockClose string
+}
+
+var (
+	cStyle    = syntax{line: "//", blockOpen: "/*", blockClose: "*/"}
+	hashStyle = syntax{line: "#"}
+)
+
+var syntaxes = map[string]syntax{
+	"bash":       hashStyle,
+	"c":          cStyle,
+	"cpp":        cStyle,
+	"csharp":     cStyle,
+	"css":        {blockOpen: "/*", blockClose: "*/"},
+	"cue":        {line: "//"},
+	"dockerfile": hashStyle,
+	"elixir":     hashStyle,
+	"elm":        {line: "--", blockOpen: "{-", blockClose: "-}"},
+	"go":         cStyle,
+	"groovy":     cStyle,
+	"hcl":        {line: "#", blockOpen: "/*", blockClose: "*/"},
+	"html":       {blockOpen: "<!--", blockClose: "-->"},
+	"java":       cStyle,
+	"javascript": cStyle,
+	"kotlin":     cStyle,
+	"ocaml":      {blockOpen: "(*", blockClose: "*)"},
+	"php":        cStyle,
+	"protobuf":   cStyle,
+	"python":     hashStyle,
+	"ruby":       hashStyle,
+	"rust":       cStyle,
+	"scala":      cStyle,
+	"sql":        {line: "--", blockOpen: "/*", blockClose: "*/"},
+	"svelte":     {blockOpen: "<!--", blockClose: "-->"},
+	"swift":      cStyle,
+	"toml":       hashStyle,
+	"tsx":        cStyle,
+	"typescript": cStyle,
+	"yaml":       hashStyle,
+}
+
+func syntaxFor(filePath string) (syntax, bool) {
+	s, ok := syntaxes[languages.GetLanguageNameForExtension(strings.ToLower(filepath.Ext(filePath)))]
+	return s, ok
+}
+
+// lineMarker returns the line comment marker text starts with, repeats of its last character included
+// so doc comments such as /// keep their marker
+func (s syntax) lineMarker(text string) (string, bool) {
+	if s.line == "" || !strings.HasPrefix(text, s.line) {
+		return "", false
+	}
+	end := len(s.line)
+	for end < len(text) && text[end] == s.line[len(s.line)-1] {
+		end++
+	}
+	return text[:end], true
+}
+
+// blockMarkers returns the opening marker text starts with, a doc marker such as /** included,
+// and the closing marker it ends with
+func (s syntax) blockMarkers(text string) (string, string, bool) {
+	if s.blockOpen == "" || !strings.HasPrefix(text, s.blockOpen) || !strings.HasSuffix(text, s.blockClose) ||
+		len(text) < len(s.blockOpen)+len(s.blockClose) {
+		return "", "", false
+	}
+	open := s.blockOpen
+	if rest := text[len(open):]; (strings.HasPrefix(rest, "*") || strings.HasPrefix(rest, "!") || strings.HasPrefix(rest, "|")) &&
+		len(rest) > len(s.blockClose) {
+		open = text[:len(open)+1]
+	}
+	return open, s.blockClose, true
+}
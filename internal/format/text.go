@@ -0,0 +1,237 @@
+package format
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// decodeLines strips the marker and one following space from each line of a line comment group
+func decodeLines(texts []string, marker string) []string {
+	lines := make([]string, len(texts))
+	for i, text := range texts {
+		body := strings.TrimRight(strings.TrimPrefix(text, marker), " \t\r\n")
+		lines[i] = strings.TrimPrefix(body, " ")
+	}
+	return lines
+}
+
+// decodeBlock returns the text lines of a block comment without its markers, its indentation and a
+// leading * decoration, the relative indentation of the lines is kept
+func decodeBlock(text, open, close string) []string {
+	inner := strings.ReplaceAll(text[len(open):len(text)-len(close)], "\r\n", "\n")
+	lines := strings.Split(inner, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t")
+	}
+	lines[0] = strings.TrimLeft(lines[0], " \t")
+
+	rest := lines[1:]
+	if starred(rest) {
+		for i, line := range rest {
+			line = strings.TrimLeft(line, " \t")
+			line = strings.TrimPrefix(line, "*")
+			rest[i] = strings.TrimPrefix(line, " ")
+		}
+	} else {
+		common := -1
+		for _, line := range rest {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			width := len(line) - len(strings.TrimLeft(line, " \t"))
+			if common < 0 || width < common {
+				common = width
+			}
+		}
+		for i, line := range rest {
+			if len(line) >= common && common > 0 {
+				rest[i] = line[common:]
+			}
+		}
+	}
+
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return []string{""}
+	}
+	return lines
+}
+
+// starred reports whether every non empty line is decorated with a leading *
+func starred(lines []string) bool {
+	found := false
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "*") {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// encodeLines writes text lines as line comments, the first line is not indented
+func encodeLines(lines []string, marker, indent, newline string) string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = marker
+		if line != "" {
+			out[i] += " " + line
+		}
+	}
+	return strings.Join(out, newline+indent)
+}
+
+// encodeBlock writes text lines as a block comment, the first line is not indented. Multi-line
+// C style comments get a * on every line, others are aligned after the opening marker.
+func encodeBlock(lines []string, open, close, indent, newline string) string {
+	if len(lines) == 1 {
+		if lines[0] == "" {
+			return open + " " + close
+		}
+		return open + " " + lines[0] + " " + close
+	}
+
+	var sb strings.Builder
+	if close == "*/" {
+		sb.WriteString(open)
+		for _, line := range lines {
+			sb.WriteString(newline + indent + " *")
+			if line != "" {
+				sb.WriteString(" " + line)
+			}
+		}
+		sb.WriteString(newline + indent + " " + close)
+		return sb.String()
+	}
+
+	pad := strings.Repeat(" ", len(open)+1)
+	sb.WriteString(open + " " + lines[0])
+	for _, line := range lines[1:] {
+		sb.WriteString(newline)
+		if line != "" {
+			sb.WriteString(indent + pad + line)
+		}
+	}
+	sb.WriteString(" " + close)
+	return sb.String()
+}
+
+// reflow joins the lines of each paragraph and wraps them at width. Blank lines, list items, tags
+// such as @param, markup lines, decorations such as ----- and indented lines such as code examples
+// are kept as they are.
+func reflow(lines []string, width int) []string {
+	var out []string
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			out = append(out, wrapWords(strings.Fields(strings.Join(paragraph, " ")), width)...)
+			paragraph = nil
+		}
+	}
+
+	for _, line := range lines {
+		switch {
+		case strings.TrimSpace(line) == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") ||
+			strings.HasPrefix(line, "<") || isDecorationLine(line):
+			flush()
+			out = append(out, line)
+		case isListItem(line) || strings.HasPrefix(line, "@"):
+			flush()
+			paragraph = append(paragraph, line)
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return out
+}
+
+func wrapWords(words []string, width int) []string {
+	var lines []string
+	current := ""
+	for _, word := range words {
+		if current == "" {
+			current = word
+			continue
+		}
+		if utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, current)
+			current = word
+			continue
+		}
+		current += " " + word
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// isDecorationLine reports whether a line has no letters or digits
+func isDecorationLine(line string) bool {
+	return strings.IndexFunc(line, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0
+}
+
+func isListItem(line string) bool {
+	for _, bullet := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(line, bullet) {
+			return true
+		}
+	}
+	digits := strings.TrimLeftFunc(line, unicode.IsDigit)
+	return len(digits) < len(line) && (strings.HasPrefix(digits, ". ") || strings.HasPrefix(digits, ") "))
+}
+
+// capitalize upper cases the first letter of the first line unless the first word looks like code
+// or names an identifier in the code that follows, as in Go doc comments
+func capitalize(lines []string, code string) []string {
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		word := strings.Fields(line)[0]
+		first, size := utf8.DecodeRuneInString(line)
+		if !unicode.IsLower(first) || !isPlainWord(word) || containsWord(code, word) {
+			return lines
+		}
+		capitalized := append([]string{}, lines...)
+		capitalized[i] = string(unicode.ToUpper(first)) + line[size:]
+		return capitalized
+	}
+	return lines
+}
+
+// isPlainWord reports whether a word is lower case letters, optionally followed by punctuation
+func isPlainWord(word string) bool {
+	word = strings.TrimRight(word, ".,:;!?")
+	if word == "" {
+		return false
+	}
+	for _, r := range word {
+		if !unicode.IsLower(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsWord(code, word string) bool {
+	word = strings.TrimRight(word, ".,:;!?")
+	isIdent := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	for _, field := range strings.FieldsFunc(code, func(r rune) bool { return !isIdent(r) }) {
+		if field == word {
+			return true
+		}
+	}
+	return false
+}
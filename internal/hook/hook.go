@@ -0,0 +1,144 @@
+package hook
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"walle/internal/source"
+
+	"github.com/go-git/go-git/v5/config"
+)
+
+// Dir returns the directory git runs hooks from, honouring core.hooksPath
+func Dir() (string, error) {
+	repo, err := source.OpenRepository()
+	if err != nil {
+		return "", err
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", fmt.Errorf("failed to read git config: %w", err)
+	}
+	hooksPath := cfg.Raw.Section("core").Option("hooksPath")
+	if hooksPath == "" {
+		// ConfigScoped does not merge the raw sections of the global config into the local one
+		global, err := config.LoadConfig(config.GlobalScope)
+		if err != nil {
+			return "", fmt.Errorf("failed to read global git config: %w", err)
+		}
+		hooksPath = global.Raw.Section("core").Option("hooksPath")
+	}
+	if hooksPath != "" {
+		if rest, ok := strings.CutPrefix(hooksPath, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(home, rest), nil
+		}
+		if filepath.IsAbs(hooksPath) {
+			return hooksPath, nil
+		}
+		// A relative hooksPath is relative to the root of the worktree
+		root, err := source.RepoRoot()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(root, hooksPath), nil
+	}
+
+	gitDir, err := source.GitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, "hooks"), nil
+}
+
+// Install writes the walle pre-commit hook into dir. An existing hook is kept and run before walle,
+// installing again only rewrites the walle hook.
+func Install(dir string, fix bool) (Result, error) {
+	path := filepath.Join(dir, Name)
+	result := Result{Path: path}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return result, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	existing, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return result, fmt.Errorf("failed to read existing hook: %w", err)
+	case !isWalle(existing):
+		chained := path + chainedSuffix
+		if _, err := os.Stat(chained); err == nil {
+			return result, fmt.Errorf("both %s and %s exist, remove one of them first", path, chained)
+		}
+		if err := os.Rename(path, chained); err != nil {
+			return result, fmt.Errorf("failed to keep existing hook: %w", err)
+		}
+	}
+	if _, err := os.Stat(path + chainedSuffix); err == nil {
+		result.Chained = true
+	}
+
+	if err := os.WriteFile(path, []byte(script(fix)), 0755); err != nil {
+		return result, fmt.Errorf("failed to write hook: %w", err)
+	}
+	// WriteFile keeps the mode of a file that already existed
+	if err := os.Chmod(path, 0755); err != nil {
+		return result, fmt.Errorf("failed to make hook executable: %w", err)
+	}
+	return result, nil
+}
+
+// Uninstall removes the walle pre-commit hook from dir and puts back the hook it chained
+func Uninstall(dir string) (Result, error) {
+	path := filepath.Join(dir, Name)
+	result := Result{Path: path}
+
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return result, ErrNotInstalled
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to read hook: %w", err)
+	}
+	if !isWalle(existing) {
+		return result, ErrNotInstalled
+	}
+
+	if err := os.Remove(path); err != nil {
+		return result, fmt.Errorf("failed to remove hook: %w", err)
+	}
+	if _, err := os.Stat(path + chainedSuffix); err == nil {
+		if err := os.Rename(path+chainedSuffix, path); err != nil {
+			return result, fmt.Errorf("failed to restore previous hook: %w", err)
+		}
+		result.Chained = true
+	}
+	return result, nil
+}
+
+func isWalle(content []byte) bool {
+	return bytes.Contains(content, []byte(marker))
+}
+
+// script is the hook, it runs a chained hook first and stops the commit when either fails
+func script(fix bool) string {
+	command := "walle hook run"
+	if fix {
+		command += " --fix"
+	}
+	return `#!/bin/sh
+` + marker + `, remove with walle hook uninstall
+chained="$0` + chainedSuffix + `"
+if [ -x "$chained" ]; then
+	"$chained" "$@" || exit $?
+fi
+exec ` + command + `
+`
+}
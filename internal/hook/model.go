@@ -0,0 +1,23 @@
+package hook
+
+import "errors"
+
+const (
+	// Name is the git hook walle installs
+	Name = "pre-commit"
+	// chainedSuffix is appended to the name of a hook that was there before walle, it is run first
+	chainedSuffix = ".walle-chained"
+	// marker identifies a hook written by walle
+	marker = "# Installed by walle hook install"
+)
+
+// ErrNotInstalled is returned by Uninstall when the hook was not written by walle
+var ErrNotInstalled = errors.New("the pre-commit hook was not installed by walle")
+
+// Result describes what Install or Uninstall did
+type Result struct {
+	// Path is the hook file
+	Path string
+	// Chained is set when an existing hook is run before walle, or was put back by Uninstall
+	Chained bool
+}
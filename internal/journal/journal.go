@@ -0,0 +1,294 @@
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"walle/internal/comment"
+	"walle/internal/source"
+)
+
+// maxRuns is the number of runs kept, older runs are pruned when a new one is saved
+const maxRuns = 50
+
+var ErrNoRuns = errors.New("no fix runs recorded")
+
+// Dir returns the journal directory, inside the git dir when available and the user cache otherwise
+func Dir() (string, error) {
+	if gitDir, err := source.GitDir(); err == nil {
+		return filepath.Join(gitDir, "walle", "journal"), nil
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "walle", "journal", Hash([]byte(cwd))[:16]), nil
+}
+
+// Hash returns the hex encoded sha256 of content
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewRun creates a run for the planned edits, paths are stored absolute so undo works from any directory
+func NewRun(edits []comment.FileEdit) (Run, error) {
+	now := time.Now()
+	run := Run{Timestamp: now}
+
+	var paths []string
+	for _, edit := range edits {
+		absPath, err := filepath.Abs(edit.Path)
+		if err != nil {
+			return Run{}, err
+		}
+		paths = append(paths, absPath)
+		run.Files = append(run.Files, Entry{
+			Path:         absPath,
+			Comments:     len(edit.Comments),
+			OriginalHash: Hash(edit.Original),
+			UpdatedHash:  Hash(edit.Updated),
+			Original:     edit.Original,
+		})
+	}
+
+	run.ID = now.Format("20060102-150405") + "-" + Hash([]byte(now.String() + strings.Join(paths, "\n")))[:6]
+	return run, nil
+}
+
+// Save writes a run to the journal and prunes the oldest runs
+func Save(run Run) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create journal dir: %w", err)
+	}
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, run.ID+".json"), data, 0600); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	return prune(dir)
+}
+
+// Load reads a run by its id
+func Load(id string) (Run, error) {
+	// The id names a file in the journal dir, it must not reach outside it
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return Run{}, fmt.Errorf("invalid fix run id %q", id)
+	}
+	dir, err := Dir()
+	if err != nil {
+		return Run{}, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Run{}, fmt.Errorf("no fix run with id %s", id)
+		}
+		return Run{}, err
+	}
+
+	var run Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return Run{}, fmt.Errorf("corrupt journal %s: %w", id, err)
+	}
+	return run, nil
+}
+
+// List returns all recorded runs, newest first
+func List() ([]Run, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := runIDs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []Run
+	for i := len(ids) - 1; i >= 0; i-- {
+		run, err := Load(ids[i])
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// Latest returns the newest run that has not been undone yet
+func Latest() (Run, error) {
+	runs, err := List()
+	if err != nil {
+		return Run{}, err
+	}
+	for _, run := range runs {
+		if run.UndoneAt == nil {
+			return run, nil
+		}
+	}
+	return Run{}, ErrNoRuns
+}
+
+// Write journals a single edit and writes it, so walle undo restores the file
+func Write(edit comment.FileEdit) (Run, error) {
+	run, failed, err := WriteAll([]comment.FileEdit{edit})
+	if err != nil {
+		return Run{}, err
+	}
+	if failed[0] != nil {
+		return Run{}, failed[0]
+	}
+	return run, nil
+}
+
+// WriteAll journals the edits and writes them, so walle undo restores the files. failed holds the
+// error of each edit at its index, nil when it was written. Files that were not written are left out
+// of the run, when none was written the run is dropped and has no ID.
+func WriteAll(edits []comment.FileEdit) (run Run, failed []error, err error) {
+	run, err = NewRun(edits)
+	if err != nil {
+		return Run{}, nil, fmt.Errorf("failed to create undo journal: %w", err)
+	}
+	if err := Save(run); err != nil {
+		return Run{}, nil, fmt.Errorf("failed to write undo journal: %w", err)
+	}
+
+	failed = make([]error, len(edits))
+	var entries []Entry
+	for i, edit := range edits {
+		if failed[i] = comment.WriteEdit(edit); failed[i] == nil {
+			entries = append(entries, run.Files[i])
+		}
+	}
+	if len(entries) == 0 {
+		// An empty run would be the one walle undo picks instead of the last real one
+		if err := remove(run.ID); err != nil {
+			return Run{}, failed, fmt.Errorf("failed to remove undo journal: %w", err)
+		}
+		return Run{}, failed, nil
+	}
+	if len(entries) < len(run.Files) {
+		run.Files = entries
+		if err := Save(run); err != nil {
+			return run, failed, fmt.Errorf("failed to update undo journal: %w", err)
+		}
+	}
+	return run, failed, nil
+}
+
+// Restore writes the original content back for every file that is unchanged since the fix.
+// Files that were modified afterwards are refused so no work is lost.
+func Restore(run Run) ([]string, []Refusal, error) {
+	var restored []string
+	var refused []Refusal
+
+	for _, entry := range run.Files {
+		current, err := os.ReadFile(entry.Path)
+		if err != nil {
+			refused = append(refused, Refusal{Path: entry.Path, Reason: err.Error()})
+			continue
+		}
+
+		if Hash(current) != entry.UpdatedHash {
+			refused = append(refused, Refusal{Path: entry.Path, Reason: "file changed after the fix"})
+			continue
+		}
+
+		err = comment.WriteEdit(comment.FileEdit{Path: entry.Path, Original: current, Updated: entry.Original})
+		if err != nil {
+			refused = append(refused, Refusal{Path: entry.Path, Reason: err.Error()})
+			continue
+		}
+		restored = append(restored, entry.Path)
+	}
+
+	if len(refused) == 0 {
+		now := time.Now()
+		run.UndoneAt = &now
+	} else {
+		// Keep the refused files undoable once they are reverted by hand
+		run.Files = keepRefused(run.Files, refused)
+	}
+
+	return restored, refused, Save(run)
+}
+
+// remove deletes a run from the journal
+func remove(id string) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(dir, id+".json"))
+}
+
+func keepRefused(entries []Entry, refused []Refusal) []Entry {
+	var kept []Entry
+	for _, entry := range entries {
+		for _, r := range refused {
+			if r.Path == entry.Path {
+				kept = append(kept, entry)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// runIDs lists the run ids in the journal dir, oldest first
+func runIDs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			ids = append(ids, strings.TrimSuffix(entry.Name(), ".json"))
+		}
+	}
+	// IDs start with a timestamp so they sort chronologically
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func prune(dir string) error {
+	ids, err := runIDs(dir)
+	if err != nil {
+		return err
+	}
+	for len(ids) > maxRuns {
+		if err := os.Remove(filepath.Join(dir, ids[0]+".json")); err != nil {
+			return err
+		}
+		ids = ids[1:]
+	}
+	return nil
+}
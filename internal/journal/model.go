@@ -0,0 +1,26 @@
+package journal
+
+import "time"
+
+// Run records everything needed to undo a single fix run
+type Run struct {
+	ID        string     `json:"id"`
+	Timestamp time.Time  `json:"timestamp"`
+	Files     []Entry    `json:"files"`
+	UndoneAt  *time.Time `json:"undone_at,omitempty"`
+}
+
+// Entry is a single file changed by a fix run
+type Entry struct {
+	Path         string `json:"path"`
+	Comments     int    `json:"comments"`
+	OriginalHash string `json:"original_hash"`
+	UpdatedHash  string `json:"updated_hash"`
+	Original     []byte `json:"original"`
+}
+
+// Refusal is a file that was not restored because it changed after the fix
+type Refusal struct {
+	Path   string
+	Reason string
+}
@@ -167,12 +167,17 @@ var SupportedLanguages = map[string]LanguageConfig{
 // extensionToLanguage maps file extensions to their language configuration
 var extensionToLanguage map[string]*LanguageConfig
 
+// extensionToName maps file extensions to their language name
+var extensionToName map[string]string
+
 func init() {
 	extensionToLanguage = make(map[string]*LanguageConfig)
+	extensionToName = make(map[string]string)
 	for langName := range SupportedLanguages {
 		config := SupportedLanguages[langName]
 		for _, ext := range config.Extensions {
 			extensionToLanguage[ext] = &config
+			extensionToName[ext] = langName
 		}
 	}
 }
@@ -191,6 +196,11 @@ func GetLanguageForExtension(ext string) *sitter.Language {
 	return nil
 }
 
+// GetLanguageNameForExtension returns the language name for a file extension, or "" if unsupported
+func GetLanguageNameForExtension(ext string) string {
+	return extensionToName[ext]
+}
+
 // GetSupportedLanguageNames returns a sorted list of all supported language names
 func GetSupportedLanguageNames() []string {
 	names := make([]string, 0, len(SupportedLanguages))
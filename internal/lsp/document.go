@@ -0,0 +1,182 @@
+package lsp
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+	"walle/internal/comment"
+	"walle/internal/source"
+)
+
+// document is an open text document, the editor's buffer rather than the file on disk
+type document struct {
+	uri     string
+	path    string
+	version int
+	text    []byte
+	// head is the content in HEAD, comments on lines that differ from it are new
+	head    []byte
+	tracked bool
+	// comments are the new comments in text, refreshed after every change
+	comments []comment.Comment
+}
+
+// pathFromURI converts a file URI into a local path
+func pathFromURI(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URI scheme %q", u.Scheme)
+	}
+	path := u.Path
+	// file:///C:/dir becomes C:/dir on Windows
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// loadHead reads the content of the document in HEAD, used as the base of the modified lines
+func (d *document) loadHead() error {
+	head, tracked, err := source.HeadFile(d.path)
+	if err != nil {
+		return err
+	}
+	d.head, d.tracked = head, tracked
+	return nil
+}
+
+// scan finds the new comments in the current text, comments in files walle doesn't support are none
+func (d *document) scan() error {
+	d.comments = nil
+	scanner, err := comment.GetScanner(d.path)
+	if err != nil {
+		return nil
+	}
+
+	file := source.File{Path: d.path, Content: d.text, Status: source.StatusAdded}
+	if d.tracked {
+		file.Status = source.StatusModified
+		file.DiffRanges = source.AddedLineRanges(d.head, d.text)
+	}
+	comments, err := scanner.Scan(file)
+	if err != nil {
+		return err
+	}
+	d.comments = comment.WithoutKept(comments)
+	return nil
+}
+
+// applyChange applies an incremental or full change sent by didChange
+func (d *document) applyChange(change contentChange, encoding string) {
+	if change.Range == nil {
+		d.text = []byte(change.Text)
+		return
+	}
+	start := offsetOf(d.text, change.Range.Start, encoding)
+	end := max(offsetOf(d.text, change.Range.End, encoding), start)
+
+	text := make([]byte, 0, len(d.text)-int(end-start)+len(change.Text))
+	text = append(text, d.text[:start]...)
+	text = append(text, change.Text...)
+	text = append(text, d.text[end:]...)
+	d.text = text
+}
+
+// editTo returns a single edit that turns the current text into updated, covering only what changed
+func (d *document) editTo(updated []byte, encoding string) TextEdit {
+	old := d.text
+	prefix := 0
+	for prefix < len(old) && prefix < len(updated) && old[prefix] == updated[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(updated)-prefix && old[len(old)-1-suffix] == updated[len(updated)-1-suffix] {
+		suffix++
+	}
+	// Positions must not point into a character or between \r and \n
+	for prefix > 0 && prefix < len(old) && (!utf8.RuneStart(old[prefix]) || old[prefix] == '\n' && old[prefix-1] == '\r') {
+		prefix--
+	}
+	for suffix > 0 && (!utf8.RuneStart(old[len(old)-suffix]) ||
+		old[len(old)-suffix] == '\n' && len(old)-suffix > 0 && old[len(old)-suffix-1] == '\r') {
+		suffix--
+	}
+
+	return TextEdit{
+		Range: Range{
+			Start: positionOf(old, uint32(prefix), encoding),
+			End:   positionOf(old, uint32(len(old)-suffix), encoding),
+		},
+		NewText: string(updated[prefix : len(updated)-suffix]),
+	}
+}
+
+// rangeOf returns the range of a comment, without a line ending some grammars include
+func (d *document) rangeOf(c comment.Comment, encoding string) Range {
+	end := c.EndByte
+	for end > c.StartByte && (d.text[end-1] == '\n' || d.text[end-1] == '\r') {
+		end--
+	}
+	return Range{Start: positionOf(d.text, c.StartByte, encoding), End: positionOf(d.text, end, encoding)}
+}
+
+// positionOf converts a byte offset into a line and a character counted in the negotiated encoding
+func positionOf(text []byte, offset uint32, encoding string) Position {
+	offset = min(offset, uint32(len(text)))
+	line := bytes.Count(text[:offset], []byte("\n"))
+	lineStart := bytes.LastIndexByte(text[:offset], '\n') + 1
+	return Position{Line: line, Character: width(text[lineStart:offset], encoding)}
+}
+
+// offsetOf converts a position into a byte offset, positions past the end of a line or the text are clamped
+func offsetOf(text []byte, pos Position, encoding string) uint32 {
+	lineStart := 0
+	for i := 0; i < pos.Line; i++ {
+		next := bytes.IndexByte(text[lineStart:], '\n')
+		if next < 0 {
+			return uint32(len(text))
+		}
+		lineStart += next + 1
+	}
+
+	lineEnd := len(text)
+	if i := bytes.IndexByte(text[lineStart:], '\n'); i >= 0 {
+		lineEnd = lineStart + i
+	}
+	line := strings.TrimSuffix(string(text[lineStart:lineEnd]), "\r")
+
+	units := 0
+	for i, r := range line {
+		if units >= pos.Character {
+			return uint32(lineStart + i)
+		}
+		units += runeWidth(r, encoding)
+	}
+	return uint32(lineStart + len(line))
+}
+
+func width(text []byte, encoding string) int {
+	if encoding == encodingUTF8 {
+		return len(text)
+	}
+	units := 0
+	for _, r := range string(text) {
+		units += runeWidth(r, encoding)
+	}
+	return units
+}
+
+func runeWidth(r rune, encoding string) int {
+	if encoding == encodingUTF8 {
+		return utf8.RuneLen(r)
+	}
+	return len(utf16.Encode([]rune{r}))
+}
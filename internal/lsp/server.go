@@ -0,0 +1,287 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"walle/internal/comment"
+)
+
+// Server is a language server that reports new comments as diagnostics and offers to remove them.
+// It works on the editor's buffers and never writes files itself.
+type Server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	log      *log.Logger
+	docs     map[string]*document
+	encoding string
+	shutdown bool
+}
+
+// NewServer creates a server reading requests from in and writing responses to out, logs go to logs
+func NewServer(in io.Reader, out io.Writer, logs io.Writer) *Server {
+	return &Server{
+		in:       bufio.NewReader(in),
+		out:      out,
+		log:      log.New(logs, "walle lsp: ", 0),
+		docs:     make(map[string]*document),
+		encoding: encodingUTF16,
+	}
+}
+
+// Run serves requests until the client sends exit or closes the input.
+// It returns an error when the client exits without asking for a shutdown first.
+func (s *Server) Run() error {
+	for {
+		body, err := readMessage(s.in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read message: %w", err)
+		}
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			s.replyError(nil, codeParseError, err.Error())
+			continue
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a message, only failures to write end the server
+func (s *Server) handle(msg message) error {
+	isRequest := len(msg.ID) > 0
+	if s.shutdown && isRequest {
+		return s.replyError(msg.ID, codeInvalidRequest, "server is shutting down")
+	}
+
+	switch msg.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.replyError(msg.ID, codeInvalidParams, err.Error())
+		}
+		return s.reply(msg.ID, s.initialize(params))
+	case "shutdown":
+		s.shutdown = true
+		return s.reply(msg.ID, nil)
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			s.log.Printf("invalid didOpen: %v", err)
+			return nil
+		}
+		return s.didOpen(params)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			s.log.Printf("invalid didChange: %v", err)
+			return nil
+		}
+		return s.didChange(params)
+	case "textDocument/didSave":
+		var params documentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			s.log.Printf("invalid didSave: %v", err)
+			return nil
+		}
+		return s.didSave(params)
+	case "textDocument/didClose":
+		var params documentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			s.log.Printf("invalid didClose: %v", err)
+			return nil
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return s.publish(params.TextDocument.URI, 0, []Diagnostic{})
+	case "textDocument/codeAction":
+		var params codeActionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return s.replyError(msg.ID, codeInvalidParams, err.Error())
+		}
+		return s.reply(msg.ID, s.codeActions(params))
+	}
+
+	if isRequest {
+		return s.replyError(msg.ID, codeMethodNotFound, "method not supported: "+msg.Method)
+	}
+	// Other notifications such as initialized and $/cancelRequest need no answer
+	return nil
+}
+
+func (s *Server) initialize(params initializeParams) map[string]any {
+	if slices.Contains(params.Capabilities.General.PositionEncodings, encodingUTF8) {
+		s.encoding = encodingUTF8
+	}
+	return map[string]any{
+		"capabilities": map[string]any{
+			"positionEncoding": s.encoding,
+			"textDocumentSync": map[string]any{
+				"openClose": true,
+				"change":    syncIncremental,
+				"save":      map[string]any{"includeText": false},
+			},
+			"codeActionProvider": map[string]any{
+				"codeActionKinds": []string{"quickfix"},
+			},
+		},
+		"serverInfo": map[string]any{"name": "walle"},
+	}
+}
+
+func (s *Server) didOpen(params didOpenParams) error {
+	path, err := pathFromURI(params.TextDocument.URI)
+	if err != nil {
+		s.log.Printf("skipping %s: %v", params.TextDocument.URI, err)
+		return nil
+	}
+	doc := &document{
+		uri:     params.TextDocument.URI,
+		path:    path,
+		version: params.TextDocument.Version,
+		text:    []byte(params.TextDocument.Text),
+	}
+	if err := doc.loadHead(); err != nil {
+		s.log.Printf("reading %s from HEAD: %v", path, err)
+	}
+	s.docs[doc.uri] = doc
+	return s.refresh(doc)
+}
+
+func (s *Server) didChange(params didChangeParams) error {
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	for _, change := range params.ContentChanges {
+		doc.applyChange(change, s.encoding)
+	}
+	doc.version = params.TextDocument.Version
+	return s.refresh(doc)
+}
+
+// didSave reloads HEAD, a save often follows a commit or a checkout
+func (s *Server) didSave(params documentParams) error {
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	if err := doc.loadHead(); err != nil {
+		s.log.Printf("reading %s from HEAD: %v", doc.path, err)
+	}
+	return s.refresh(doc)
+}
+
+// refresh rescans a document and publishes its diagnostics
+func (s *Server) refresh(doc *document) error {
+	if err := doc.scan(); err != nil {
+		s.log.Printf("scanning %s: %v", doc.path, err)
+	}
+	diagnostics := make([]Diagnostic, 0, len(doc.comments))
+	for _, c := range doc.comments {
+		diagnostics = append(diagnostics, s.diagnostic(doc, c))
+	}
+	return s.publish(doc.uri, doc.version, diagnostics)
+}
+
+func (s *Server) diagnostic(doc *document, c comment.Comment) Diagnostic {
+	return Diagnostic{
+		Range:    doc.rangeOf(c, s.encoding),
+		Severity: severityWarning,
+		Code:     c.ID,
+		Source:   "walle",
+		Message:  fmt.Sprintf("New %s comment", c.Kind),
+	}
+}
+
+// codeActions offers to remove or keep the comments in the requested range, and to remove every
+// new comment in the file
+func (s *Server) codeActions(params codeActionParams) []CodeAction {
+	actions := []CodeAction{}
+	doc, ok := s.docs[params.TextDocument.URI]
+	if !ok || len(doc.comments) == 0 {
+		return actions
+	}
+	if len(params.Context.Only) > 0 && !slices.Contains(params.Context.Only, "quickfix") {
+		return actions
+	}
+
+	start := offsetOf(doc.text, params.Range.Start, s.encoding)
+	end := offsetOf(doc.text, params.Range.End, s.encoding)
+	var selected []comment.Comment
+	for _, c := range doc.comments {
+		if c.StartByte <= end && start <= c.EndByte {
+			selected = append(selected, c)
+		}
+	}
+	if len(selected) == 0 {
+		return actions
+	}
+
+	for _, c := range selected {
+		diagnostics := []Diagnostic{s.diagnostic(doc, c)}
+
+		remove := s.removal(doc, "Remove comment", []comment.Comment{c})
+		remove.Diagnostics = diagnostics
+		remove.IsPreferred = true
+		actions = append(actions, remove)
+
+		actions = append(actions, CodeAction{
+			Title:       "Mark " + comment.KeepMarker,
+			Kind:        "quickfix",
+			Diagnostics: diagnostics,
+			Edit:        s.workspaceEdit(doc, comment.InsertKeepMarkers(doc.text, []comment.Comment{c})),
+		})
+	}
+	return append(actions, s.removal(doc, "Remove all comments in file", doc.comments))
+}
+
+// removal plans removing comments from the buffer like walle fix does, including its cleanup and
+// repairs. A removal that would change the syntax tree is offered disabled with the reason.
+func (s *Server) removal(doc *document, title string, comments []comment.Comment) CodeAction {
+	action := CodeAction{Title: title, Kind: "quickfix"}
+	updated, _ := comment.ApplyRemovals(doc.path, doc.text, comments)
+	if err := comment.Verify(doc.path, doc.text, updated); err != nil {
+		action.Disabled = &disabled{Reason: err.Error()}
+		return action
+	}
+	action.Edit = s.workspaceEdit(doc, updated)
+	return action
+}
+
+func (s *Server) workspaceEdit(doc *document, updated []byte) *WorkspaceEdit {
+	return &WorkspaceEdit{Changes: map[string][]TextEdit{doc.uri: {doc.editTo(updated, s.encoding)}}}
+}
+
+func (s *Server) publish(uri string, version int, diagnostics []Diagnostic) error {
+	return writeMessage(s.out, notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  publishDiagnosticsParams{URI: uri, Version: version, Diagnostics: diagnostics},
+	})
+}
+
+func (s *Server) reply(id json.RawMessage, result any) error {
+	return writeMessage(s.out, response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *Server) replyError(id json.RawMessage, code int, text string) error {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return writeMessage(s.out, errorResponse{JSONRPC: "2.0", ID: id, Error: responseError{Code: code, Message: text}})
+}
@@ -0,0 +1,124 @@
+package patch
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines around each hunk, the same as git
+const contextLines = 3
+
+// Unified returns a git apply compatible unified diff between two versions of a file.
+// It returns an empty string when the contents are equal.
+func Unified(path string, oldContent, newContent []byte) string {
+	if bytes.Equal(oldContent, newContent) {
+		return ""
+	}
+
+	a := splitLines(oldContent)
+	b := splitLines(newContent)
+	ops := diffLines(a, b)
+
+	name := filepath.ToSlash(path)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "diff --git a/%s b/%s\n", name, name)
+	fmt.Fprintf(&sb, "--- a/%s\n", name)
+	fmt.Fprintf(&sb, "+++ b/%s\n", name)
+
+	for _, h := range hunks(ops) {
+		writeHunk(&sb, ops[h.start:h.end], a, b)
+	}
+	return sb.String()
+}
+
+type hunkRange struct {
+	start int
+	end   int
+}
+
+// hunks groups the changed ops with their context, hunks closer than twice the context are merged
+func hunks(ops []op) []hunkRange {
+	var result []hunkRange
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == opEqual {
+			continue
+		}
+
+		start := max(i-contextLines, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			// Look ahead for the next change within reach of this hunk's context
+			next := end
+			for next < len(ops) && ops[next].kind == opEqual {
+				next++
+			}
+			if next < len(ops) && next-end <= 2*contextLines {
+				end = next
+				continue
+			}
+			end = min(end+contextLines, len(ops))
+			break
+		}
+
+		if len(result) > 0 && start <= result[len(result)-1].end {
+			result[len(result)-1].end = end
+		} else {
+			result = append(result, hunkRange{start: start, end: end})
+		}
+		i = end - 1
+	}
+	return result
+}
+
+func writeHunk(sb *strings.Builder, ops []op, a, b [][]byte) {
+	oldStart, newStart := ops[0].oldIndex, ops[0].newIndex
+	oldCount, newCount := 0, 0
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			oldCount++
+			newCount++
+		case opDelete:
+			oldCount++
+		case opInsert:
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkSpan(oldStart, oldCount), hunkSpan(newStart, newCount))
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			writeLine(sb, ' ', a[o.oldIndex])
+		case opDelete:
+			writeLine(sb, '-', a[o.oldIndex])
+		case opInsert:
+			writeLine(sb, '+', b[o.newIndex])
+		}
+	}
+}
+
+// hunkSpan formats a hunk range, an empty range refers to the line before it
+func hunkSpan(index, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", index)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", index+1)
+	}
+	return fmt.Sprintf("%d,%d", index+1, count)
+}
+
+func writeLine(sb *strings.Builder, prefix byte, line []byte) {
+	sb.WriteByte(prefix)
+	sb.Write(line)
+	if !bytes.HasSuffix(line, []byte("\n")) {
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
@@ -0,0 +1,136 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"walle/internal/comment"
+	"walle/internal/secret"
+	"walle/internal/source"
+)
+
+// DefaultCommitTemplate lists every removed comment so the explanations stay in the history
+const DefaultCommitTemplate = `Remove {{.Count}} comments from {{len .Files}} files
+
+{{range .Comments}}{{.Path}}:{{.Line}}: {{.Text}}
+{{end}}`
+
+type CommitOptions struct {
+	// Template is a text/template for the message, empty uses DefaultCommitTemplate
+	Template string
+	Author   string
+	Amend    bool
+	// Fixup creates a "fixup!" commit for this revision
+	Fixup string
+}
+
+// CommitData is passed to the commit message template
+type CommitData struct {
+	Count    int
+	Files    []string
+	Comments []CommitComment
+}
+
+type CommitComment struct {
+	Path string
+	Line int
+	// Text is the comment with continuation lines indented and secrets redacted
+	Text string
+}
+
+// CommitPipeline stages the written files and commits them with the removed comments in the message
+func CommitPipeline(edits []comment.FileEdit, commitOpts CommitOptions, pipeOpts Options) error {
+	out := pipeOpts.renderer()
+
+	if len(edits) == 0 {
+		return nil
+	}
+
+	message, err := CommitMessage(edits, commitOpts.Template)
+	if err != nil {
+		return err
+	}
+
+	if commitOpts.Fixup != "" {
+		subject, err := source.CommitSubject(commitOpts.Fixup)
+		if err != nil {
+			return err
+		}
+		message = "fixup! " + subject + "\n\n" + message
+	} else if commitOpts.Amend {
+		// Keep the message of the amended commit and add the removed comments to it
+		previous, err := source.CommitMessage("HEAD")
+		if err != nil {
+			return err
+		}
+		message = strings.TrimSpace(previous) + "\n\n" + message
+	}
+
+	var files []string
+	for _, edit := range edits {
+		files = append(files, edit.Path)
+	}
+
+	hash, err := source.Commit(source.CommitOptions{
+		Files:   files,
+		Message: message,
+		Author:  commitOpts.Author,
+		Amend:   commitOpts.Amend,
+	})
+	if err != nil {
+		return err
+	}
+
+	out.Success("📦 Committed %d files as %s", len(files), hash.String()[:7])
+	return nil
+}
+
+// CommitMessage renders the commit message for the removed comments
+func CommitMessage(edits []comment.FileEdit, tmpl string) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultCommitTemplate
+	}
+
+	t, err := template.New("commit").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid commit template: %w", err)
+	}
+
+	var data CommitData
+	for _, edit := range edits {
+		data.Files = append(data.Files, edit.Path)
+		comments := make([]comment.Comment, len(edit.Comments))
+		copy(comments, edit.Comments)
+		sort.Slice(comments, func(i, j int) bool {
+			return comments[i].StartByte < comments[j].StartByte
+		})
+		for _, c := range comments {
+			data.Comments = append(data.Comments, CommitComment{
+				Path: edit.Path,
+				Line: c.Line,
+				Text: strings.ReplaceAll(strings.TrimSpace(secret.RedactText(c.Text)), "\n", "\n    "),
+			})
+		}
+	}
+	data.Count = len(data.Comments)
+
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render commit template: %w", err)
+	}
+	return strings.TrimSpace(sb.String()) + "\n", nil
+}
+
+// LoadCommitTemplate reads a commit message template from a file
+func LoadCommitTemplate(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit template: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"walle/internal/comment"
+	"walle/internal/format"
+)
+
+// FormatPlanPipeline computes the formatted content of every file without writing anything.
+// Files where no comment changes are left out.
+func FormatPlanPipeline(comments []comment.Comment, opts format.Options, pipeOpts Options) []comment.FileEdit {
+	out := pipeOpts.renderer()
+
+	tasks := make(map[string][]comment.Comment)
+	for _, cmt := range comments {
+		tasks[cmt.FilePath] = append(tasks[cmt.FilePath], cmt)
+	}
+
+	files := make([]string, 0, len(tasks))
+	for file := range tasks {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var edits []comment.FileEdit
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			out.Warning("⚠️  Error reading %s: %v", file, err)
+			continue
+		}
+
+		edit, err := format.File(file, content, tasks[file], opts)
+		if err != nil {
+			var verifyErr *comment.VerifyError
+			if errors.As(err, &verifyErr) {
+				out.Warning("⚠️  Skipping %s, file left untouched: %s", file, verifyErr.Reason)
+			} else if errors.Is(err, comment.ErrModified) {
+				out.Warning("⚠️  Skipping %s, it changed since it was scanned", file)
+			} else {
+				out.Warning("⚠️  Error formatting %s: %v", file, err)
+			}
+			continue
+		}
+		if len(edit.Comments) == 0 {
+			continue
+		}
+		edits = append(edits, edit)
+	}
+	return edits
+}
+
+// FormatPipeline writes the formatted files, the original content is journaled first so the run can be undone
+func FormatPipeline(edits []comment.FileEdit, pipeOpts Options) error {
+	out := pipeOpts.renderer()
+
+	run, failed, err := Write(edits, true, pipeOpts)
+	if err != nil && run.ID == "" {
+		return err
+	}
+	if err != nil {
+		out.Warning("⚠️  Error updating undo journal: %v", err)
+	}
+
+	formattedCount := 0
+	for i, edit := range edits {
+		if failed[i] != nil {
+			out.Warning("⚠️  Error formatting comments in %s: %v", edit.Path, failed[i])
+			continue
+		}
+		out.Success("✨ Formatted %d comments in %s", len(edit.Comments), edit.Path)
+		formattedCount += len(edit.Comments)
+	}
+
+	out.Info("")
+	out.Summary("✨ Formatted %d comments total.", formattedCount)
+	if run.ID != "" {
+		out.Info("Undo with: walle undo %s", run.ID)
+	}
+	return nil
+}
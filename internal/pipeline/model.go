@@ -1,5 +1,150 @@
 package pipeline
 
+import (
+	"context"
+	"strings"
+	"walle/internal/comment"
+	"walle/internal/render"
+	"walle/internal/source"
+)
+
 type Options struct {
-	Verbose bool
+	Verbose  bool
+	Progress bool
+	// Quiet leaves out the per file output and the summary, warnings are still printed
+	Quiet    bool
+	Renderer *render.Renderer
+	// Filter drops the scanned comments it returns false for, it may add findings to the ones it keeps.
+	// nil keeps every comment.
+	Filter func(*comment.Comment) bool
+	// IncludeKept scans the comments with the keep marker too, for filters that rewrite rather than remove
+	IncludeKept bool
+	// Check adds findings to every scanned comment before Filter, comments with the keep marker included.
+	// Their high severity findings are printed.
+	Check func(*comment.Comment)
+	// OnEvent receives the events of Scan, Plan and Write one at a time, it may be nil
+	OnEvent func(Event)
+	// Context stops Scan and Plan early when it is done, nil never stops them
+	Context context.Context
+}
+
+// filter applies Filter, keeping the findings it adds
+func (o Options) filter(comments []comment.Comment) []comment.Comment {
+	if o.Filter == nil {
+		return comments
+	}
+	kept := comments[:0]
+	for _, c := range comments {
+		if o.Filter(&c) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// check runs Check and takes out the comments with the keep marker, unless IncludeKept asks for them.
+// The scanners return every comment, the keep marker only matters for what gets reported or removed.
+func (o Options) check(comments []comment.Comment) ([]comment.Comment, []comment.Comment) {
+	var checked, kept []comment.Comment
+	for _, c := range comments {
+		if o.Check != nil {
+			o.Check(&c)
+		}
+		if !o.IncludeKept && strings.Contains(c.Text, comment.KeepMarker) {
+			kept = append(kept, c)
+		} else {
+			checked = append(checked, c)
+		}
+	}
+	return checked, kept
+}
+
+func (o Options) renderer() *render.Renderer {
+	if o.Renderer == nil {
+		return render.Default()
+	}
+	return o.Renderer
+}
+
+// EventType tells what an Event reports
+type EventType int
+
+const (
+	// EventFilesListed is sent before scanning, Count holds the number of files
+	EventFilesListed EventType = iota
+	// EventFileScanned is sent for every parsed file, Count holds the comments left after filtering
+	EventFileScanned
+	// EventFileSkipped is sent for every file in a language walle doesn't parse
+	EventFileSkipped
+	// EventWarning is sent for a file that could not be scanned, planned or written, Err holds why
+	EventWarning
+	// EventRepair is sent for every repair of a planned edit, Line and Message describe it
+	EventRepair
+	// EventFileWritten is sent for every edit written to disk
+	EventFileWritten
+)
+
+// Event reports the progress of Scan, Plan and Write
+type Event struct {
+	Type    EventType
+	Path    string
+	Line    int
+	Count   int
+	Message string
+	Err     error
+	Edit    *comment.FileEdit
+}
+
+// FileComments are the comments found in a file, in file order
+type FileComments struct {
+	File     source.File
+	Comments []comment.Comment
+}
+
+// FileError is a file that was left out, with the reason
+type FileError struct {
+	Path string
+	Err  error
+}
+
+// ScanResult is the outcome of Scan
+type ScanResult struct {
+	// Files holds the files with comments left after filtering, sorted by path
+	Files []FileComments
+	// Kept holds the comments with the keep marker, unless IncludeKept is set
+	Kept []comment.Comment
+	// Failed holds the files that could not be parsed, sorted by path
+	Failed []FileError
+	// Scanned is the number of files that were parsed
+	Scanned int
+}
+
+// Comments returns the comments of every file, in path and file order
+func (r ScanResult) Comments() []comment.Comment {
+	var comments []comment.Comment
+	for _, file := range r.Files {
+		comments = append(comments, file.Comments...)
+	}
+	return comments
+}
+
+// PlanResult is the outcome of Plan
+type PlanResult struct {
+	// Edits holds the planned edits, sorted by path
+	Edits []comment.FileEdit
+	// Skipped holds the files left untouched, sorted by path
+	Skipped []FileError
+}
+
+func (o Options) emit(event Event) {
+	if o.OnEvent != nil {
+		o.OnEvent(event)
+	}
+}
+
+func (o Options) context() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
 }
@@ -1,84 +1,155 @@
 package pipeline
 
 import (
+	"errors"
 	"fmt"
-	"strings"
-	"sync"
+	"sort"
 	"walle/internal/comment"
+	"walle/internal/render"
 	"walle/internal/source"
 )
 
+// ScanPipeline is Scan for the CLI, it prints the files with their comments, the warnings and a summary
 func ScanPipeline(scanOpts *source.ScanOptions, pipeOpts Options) ([]comment.Comment, error) {
-	gitScanner := &source.GitScanner{}
+	out := pipeOpts.renderer()
 
-	files, err := gitScanner.GetFiles(*scanOpts)
+	progress := &render.Progress{}
+	opts := pipeOpts
+	opts.OnEvent = func(event Event) {
+		switch event.Type {
+		case EventFilesListed:
+			if pipeOpts.Progress {
+				progress = out.StartProgress(event.Count)
+			}
+		case EventFileScanned, EventFileSkipped, EventWarning:
+			progress.Increment(event.Path)
+		}
+		pipeOpts.emit(event)
+	}
+	result, err := Scan(scanOpts, opts)
+	progress.Stop()
 	if err != nil {
 		return nil, err
 	}
 
-	wg := &sync.WaitGroup{}
-	mu := &sync.Mutex{}
-
-	var totalComments []comment.Comment
-	filesWithComments := 0
-	for _, file := range files {
-		wg.Add(1)
+	var warnings []string
+	for _, failed := range result.Failed {
+		warnings = append(warnings, "⚠️  Parse error scanning "+failed.Path+": "+failed.Err.Error())
+	}
+	warnings = append(warnings, highFindings(result.Kept, " (walle:keep)")...)
+	sort.Strings(warnings)
 
-		go func(file source.File) {
-			defer wg.Done()
-			commentScanner, err := comment.GetScanner(file.Path)
-			if err != nil {
-				return
+	for _, file := range result.Files {
+		if pipeOpts.Quiet {
+			continue
+		}
+		out.FileHeader(file.File.Path, len(file.Comments))
+		if pipeOpts.Verbose {
+			for _, c := range file.Comments {
+				out.Snippet(file.File.Content, c)
 			}
+			continue
+		}
+		// Snippets show every finding, without them the urgent ones still need to be seen
+		for _, warning := range highFindings(file.Comments, "") {
+			out.Warning("%s", warning)
+		}
+	}
+	for _, warning := range warnings {
+		out.Warning("%s", warning)
+	}
 
-			comments, err := commentScanner.Scan(file)
-			if err != nil {
-				mu.Lock()
-				fmt.Printf("⚠️  Parse error scanning %s: %v\n", file.Path, err)
-				mu.Unlock()
-				return
-			}
+	totalComments := result.Comments()
+	if !pipeOpts.Quiet {
+		out.Summary("Found %d comments in %d files", len(totalComments), len(result.Files))
+	}
+	return totalComments, nil
+}
 
-			count := len(comments)
-			if count == 0 {
-				return
+// highFindings returns a warning for every high severity finding of the comments
+func highFindings(comments []comment.Comment, suffix string) []string {
+	var warnings []string
+	for _, c := range comments {
+		for _, f := range c.Findings {
+			if f.Severity == comment.SeverityHigh {
+				warnings = append(warnings, fmt.Sprintf("⚠️  %s:%d %s: %s%s", c.FilePath, c.Line, f.Check, f.Message, suffix))
 			}
+		}
+	}
+	return warnings
+}
 
-			mu.Lock()
-			totalComments = append(totalComments, comments...)
-			filesWithComments++
-			fmt.Printf("Found %d comments in %s\n", count, file.Path)
-			if pipeOpts.Verbose {
-				for _, c := range comments {
-					fmt.Printf("\t- Line %d: %s\n", c.Line, strings.ReplaceAll(strings.ReplaceAll(c.Text, "\n", " "), "\r", " "))
-				}
+// PlanPipeline computes the new content of every file without writing anything
+func PlanPipeline(comments []comment.Comment, pipeOpts Options) []comment.FileEdit {
+	return PlanEditsPipeline(comments, nil, pipeOpts)
+}
+
+// PlanEditsPipeline is PlanPipeline for files that also have comments rewritten in place
+func PlanEditsPipeline(comments []comment.Comment, rewrites []comment.Rewrite, pipeOpts Options) []comment.FileEdit {
+	out := pipeOpts.renderer()
+
+	opts := pipeOpts
+	opts.OnEvent = func(event Event) {
+		switch event.Type {
+		case EventWarning:
+			var verifyErr *comment.VerifyError
+			if errors.As(event.Err, &verifyErr) {
+				out.Warning("⚠️  Skipping %s, file left untouched: %s", event.Path, verifyErr.Reason)
+			} else if errors.Is(event.Err, comment.ErrModified) {
+				out.Warning("⚠️  Skipping %s, it changed since it was scanned", event.Path)
+			} else {
+				out.Warning("⚠️  Error reading %s: %v", event.Path, event.Err)
 			}
-			mu.Unlock()
-		}(file)
+		case EventRepair:
+			out.Info("🔧 Repaired %s:%d, %s", event.Path, event.Line, event.Message)
+		}
+		pipeOpts.emit(event)
 	}
-	wg.Wait()
-	fmt.Printf("Found %d comments in %d files\n", len(totalComments), filesWithComments)
-	return totalComments, nil
+	// Without a Context Plan can't fail
+	result, _ := Plan(comments, rewrites, opts)
+	return result.Edits
 }
 
-func TrashPipeline(comments []comment.Comment) error {
+// TrashPipeline writes the edits to disk, the original content is journaled first so the run can be undone
+// It returns the edits that were written successfully.
+func TrashPipeline(edits []comment.FileEdit, pipeOpts Options) ([]comment.FileEdit, error) {
+	out := pipeOpts.renderer()
 
-	tasks := make(map[string][]comment.Comment)
-	for _, cmt := range comments {
-		tasks[cmt.FilePath] = append(tasks[cmt.FilePath], cmt)
+	run, failed, err := Write(edits, true, pipeOpts)
+	if err != nil && run.ID == "" {
+		return nil, err
+	}
+	if err != nil {
+		out.Warning("⚠️  Error updating undo journal: %v", err)
 	}
 
 	removedCount := 0
-	for file, comments := range tasks {
-		err := comment.RemoveComments(file, comments)
-		if err != nil {
-			fmt.Printf("⚠️  Error deleting comments in %s: %v\n", file, err)
+	rewrittenCount := 0
+	var written []comment.FileEdit
+	for i, edit := range edits {
+		if failed[i] != nil {
+			out.Warning("⚠️  Error deleting comments in %s: %v", edit.Path, failed[i])
+		} else if len(edit.Rewrites) > 0 {
+			out.Success("✅ Removed %d and rewrote %d comments in %s", len(edit.Comments), len(edit.Rewrites), edit.Path)
+			removedCount += len(edit.Comments)
+			rewrittenCount += len(edit.Rewrites)
+			written = append(written, edit)
 		} else {
-			fmt.Printf("✅ Removed %d comments from %s\n", len(comments), file)
-			removedCount += len(comments)
+			out.Success("✅ Removed %d comments from %s", len(edit.Comments), edit.Path)
+			removedCount += len(edit.Comments)
+			written = append(written, edit)
 		}
 	}
 
-	fmt.Printf("\n🗑️  Trash compacted %d comments total.\n", removedCount)
-	return nil
+	out.Info("")
+	if removedCount > 0 || rewrittenCount == 0 {
+		out.Summary("🗑️  Trash compacted %d comments total.", removedCount)
+	}
+	if rewrittenCount > 0 {
+		out.Summary("✏️  Rewrote %d comments.", rewrittenCount)
+	}
+	if run.ID != "" {
+		out.Info("Undo with: walle undo %s", run.ID)
+	}
+	return written, nil
 }
@@ -0,0 +1,93 @@
+package pipeline
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"walle/internal/comment"
+	"walle/internal/report"
+	"walle/internal/source"
+)
+
+// LocatePipeline finds the comments of a report in the current content of their files by ID.
+// Entries that can no longer be found are reported and skipped.
+func LocatePipeline(r report.Report, pipeOpts Options) ([]comment.Comment, error) {
+	out := pipeOpts.renderer()
+
+	entries := make(map[string][]report.Entry)
+	for _, entry := range r.Comments {
+		entries[entry.Path] = append(entries[entry.Path], entry)
+	}
+	paths := make([]string, 0, len(entries))
+	for path := range entries {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var located []comment.Comment
+	var missing []report.Entry
+	files := 0
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			out.Warning("⚠️  Error reading %s: %v", path, err)
+			missing = append(missing, entries[path]...)
+			continue
+		}
+		commentScanner, err := comment.GetScanner(path)
+		if err != nil {
+			out.Warning("⚠️  Skipping %s: %v", path, err)
+			missing = append(missing, entries[path]...)
+			continue
+		}
+		comments, err := commentScanner.Scan(source.File{Path: path, Status: source.StatusAdded, Content: content})
+		if err != nil {
+			out.Warning("⚠️  Parse error scanning %s: %v", path, err)
+			missing = append(missing, entries[path]...)
+			continue
+		}
+
+		byID := make(map[string]comment.Comment)
+		for _, c := range comments {
+			// A comment marked since the report was written is not to be removed anymore
+			if !pipeOpts.IncludeKept && strings.Contains(c.Text, comment.KeepMarker) {
+				continue
+			}
+			byID[c.ID] = c
+		}
+
+		var found []comment.Comment
+		for _, entry := range entries[path] {
+			c, ok := byID[entry.ID]
+			if !ok {
+				missing = append(missing, entry)
+				continue
+			}
+			found = append(found, c)
+		}
+		found, _ = pipeOpts.check(found)
+		found = pipeOpts.filter(found)
+		if len(found) == 0 {
+			continue
+		}
+
+		sort.Slice(found, func(i, j int) bool {
+			return found[i].StartByte < found[j].StartByte
+		})
+		files++
+		located = append(located, found...)
+		out.FileHeader(path, len(found))
+		if pipeOpts.Verbose {
+			for _, c := range found {
+				out.Snippet(content, c)
+			}
+		}
+	}
+
+	for _, entry := range missing {
+		out.Warning("⚠️  Not found, skipping %s %s:%d %s", entry.ID, entry.Path, entry.Line, comment.NormalizeText(entry.Text))
+	}
+
+	out.Summary("Found %d of %d comments from the report in %d files", len(located), len(r.Comments), files)
+	return located, nil
+}
@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"sort"
+	"sync"
+	"walle/internal/comment"
+	"walle/internal/journal"
+	"walle/internal/source"
+)
+
+// Scan finds the comments of the files selected by scanOpts. Nothing is printed, progress is sent to OnEvent.
+// Files that can't be parsed are sent as EventWarning and returned in Failed.
+func Scan(scanOpts *source.ScanOptions, opts Options) (ScanResult, error) {
+	ctx := opts.context()
+	files, err := (&source.GitScanner{}).GetFiles(*scanOpts)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	opts.emit(Event{Type: EventFilesListed, Count: len(files)})
+
+	wg := &sync.WaitGroup{}
+	mu := &sync.Mutex{}
+	var result ScanResult
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+
+		go func(file source.File) {
+			defer wg.Done()
+			commentScanner, err := comment.GetScanner(file.Path)
+			if err != nil {
+				mu.Lock()
+				opts.emit(Event{Type: EventFileSkipped, Path: file.Path})
+				mu.Unlock()
+				return
+			}
+			comments, err := commentScanner.Scan(file)
+			if err != nil {
+				mu.Lock()
+				result.Failed = append(result.Failed, FileError{Path: file.Path, Err: err})
+				opts.emit(Event{Type: EventWarning, Path: file.Path, Err: err})
+				mu.Unlock()
+				return
+			}
+			comments, kept := opts.check(comments)
+			comments = opts.filter(comments)
+			sort.Slice(comments, func(i, j int) bool {
+				return comments[i].StartByte < comments[j].StartByte
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			result.Scanned++
+			result.Kept = append(result.Kept, kept...)
+			if len(comments) > 0 {
+				result.Files = append(result.Files, FileComments{File: file, Comments: comments})
+			}
+			opts.emit(Event{Type: EventFileScanned, Path: file.Path, Count: len(comments)})
+		}(file)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return ScanResult{}, err
+	}
+
+	// The scan goroutines finish in any order
+	sort.Slice(result.Files, func(i, j int) bool {
+		return result.Files[i].File.Path < result.Files[j].File.Path
+	})
+	sort.Slice(result.Failed, func(i, j int) bool {
+		return result.Failed[i].Path < result.Failed[j].Path
+	})
+	sort.SliceStable(result.Kept, func(i, j int) bool {
+		if result.Kept[i].FilePath != result.Kept[j].FilePath {
+			return result.Kept[i].FilePath < result.Kept[j].FilePath
+		}
+		return result.Kept[i].StartByte < result.Kept[j].StartByte
+	})
+	return result, nil
+}
+
+// Plan computes the new content of every file without writing anything.
+// Files that would not stay valid, or changed since they were scanned, are sent as EventWarning and skipped.
+func Plan(comments []comment.Comment, rewrites []comment.Rewrite, opts Options) (PlanResult, error) {
+	ctx := opts.context()
+
+	tasks := make(map[string][]comment.Comment)
+	for _, cmt := range comments {
+		tasks[cmt.FilePath] = append(tasks[cmt.FilePath], cmt)
+	}
+	fileRewrites := make(map[string][]comment.Rewrite)
+	for _, r := range rewrites {
+		fileRewrites[r.Comment.FilePath] = append(fileRewrites[r.Comment.FilePath], r)
+		if _, ok := tasks[r.Comment.FilePath]; !ok {
+			tasks[r.Comment.FilePath] = nil
+		}
+	}
+
+	files := make([]string, 0, len(tasks))
+	for file := range tasks {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var result PlanResult
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return PlanResult{}, err
+		}
+		edit, err := comment.PlanEdit(file, tasks[file], fileRewrites[file])
+		if err != nil {
+			result.Skipped = append(result.Skipped, FileError{Path: file, Err: err})
+			opts.emit(Event{Type: EventWarning, Path: file, Err: err})
+			continue
+		}
+		for _, repair := range edit.Repairs {
+			opts.emit(Event{Type: EventRepair, Path: file, Line: repair.Line, Message: repair.Description})
+		}
+		result.Edits = append(result.Edits, edit)
+	}
+	return result, nil
+}
+
+// Write writes the edits to disk. With journaled the original content is journaled first so the run can be
+// undone, a journal that can't be created fails the whole write. failed holds the error of every edit, nil
+// for the ones that were written. An error updating the journal afterwards is returned with a valid run.
+func Write(edits []comment.FileEdit, journaled bool, opts Options) (run journal.Run, failed []error, err error) {
+	if journaled && len(edits) > 0 {
+		run, failed, err = journal.WriteAll(edits)
+		if err != nil && run.ID == "" {
+			return run, nil, err
+		}
+	} else {
+		failed = make([]error, len(edits))
+		for i, edit := range edits {
+			failed[i] = comment.WriteEdit(edit)
+		}
+	}
+
+	for i := range edits {
+		if failed[i] != nil {
+			opts.emit(Event{Type: EventWarning, Path: edits[i].Path, Err: failed[i]})
+		} else {
+			opts.emit(Event{Type: EventFileWritten, Path: edits[i].Path, Count: len(edits[i].Comments), Edit: &edits[i]})
+		}
+	}
+	return run, failed, err
+}
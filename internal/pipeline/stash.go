@@ -0,0 +1,133 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"walle/internal/comment"
+	"walle/internal/stash"
+)
+
+// StashPipeline writes the edits and records the removed comments in the sidecar so they can be unstashed.
+// The sidecar is saved before any file is written.
+func StashPipeline(edits []comment.FileEdit, pipeOpts Options) error {
+	out := pipeOpts.renderer()
+
+	path, err := stash.Path()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+
+	// written only receives the comments of files that were written, loaded separately so the two don't share entries
+	sidecar, err := stash.Load(path)
+	if err != nil {
+		return err
+	}
+	written, err := stash.Load(path)
+	if err != nil {
+		return err
+	}
+
+	files := make([]stash.File, len(edits))
+	for i, edit := range edits {
+		files[i], err = stash.Record(dir, edit)
+		if err != nil {
+			return fmt.Errorf("failed to anchor comments in %s: %w", edit.Path, err)
+		}
+		sidecar.Add(files[i])
+	}
+	if err := stash.Save(path, sidecar); err != nil {
+		return err
+	}
+
+	stashedCount := 0
+	for i, edit := range edits {
+		if err := comment.WriteEdit(edit); err != nil {
+			out.Warning("⚠️  Error stashing comments in %s: %v", edit.Path, err)
+			continue
+		}
+		out.Success("📦 Stashed %d comments from %s", len(edit.Comments), edit.Path)
+		stashedCount += len(edit.Comments)
+		written.Add(files[i])
+	}
+
+	// Comments of files that were not written stay where they are
+	if stashedCount < countComments(files) {
+		if err := stash.Save(path, written); err != nil {
+			return err
+		}
+	}
+
+	out.Info("")
+	out.Summary("📦 Stashed %d comments in %s", stashedCount, path)
+	out.Info("Put them back with: walle unstash")
+	return nil
+}
+
+// UnstashPipeline puts the stashed comments back, comments that can't be placed stay in the sidecar
+func UnstashPipeline(pipeOpts Options) error {
+	out := pipeOpts.renderer()
+
+	path, err := stash.Path()
+	if err != nil {
+		return err
+	}
+	sidecar, err := stash.Load(path)
+	if err != nil {
+		return err
+	}
+	if len(sidecar.Files) == 0 {
+		out.Info("No stashed comments.")
+		return nil
+	}
+
+	restoredCount, leftCount := 0, 0
+	for _, file := range append([]stash.File{}, sidecar.Files...) {
+		edit, unplaced, err := stash.Restore(filepath.Dir(path), file)
+		if err != nil {
+			var verifyErr *comment.VerifyError
+			if errors.As(err, &verifyErr) {
+				out.Warning("⚠️  Skipping %s, file left untouched: %s", file.Path, verifyErr.Reason)
+			} else {
+				out.Warning("⚠️  Error unstashing %s: %v", file.Path, err)
+			}
+			leftCount += len(file.Comments)
+			continue
+		}
+		if err := comment.WriteEdit(edit); err != nil {
+			out.Warning("⚠️  Error unstashing %s: %v", file.Path, err)
+			leftCount += len(file.Comments)
+			continue
+		}
+
+		var left []stash.Entry
+		for _, u := range unplaced {
+			out.Warning("⚠️  Could not place comment from %s:%d, %s", file.Path, u.Entry.Line, u.Reason)
+			left = append(left, u.Entry)
+		}
+		restored := len(file.Comments) - len(left)
+		if restored > 0 {
+			out.Success("✅ Restored %d comments to %s", restored, file.Path)
+		}
+		restoredCount += restored
+		leftCount += len(left)
+		sidecar.Replace(file.Path, left)
+	}
+
+	if err := stash.Save(path, sidecar); err != nil {
+		return err
+	}
+
+	out.Info("")
+	out.Summary("📦 Restored %d comments, %d left in %s", restoredCount, leftCount, path)
+	return nil
+}
+
+func countComments(files []stash.File) int {
+	count := 0
+	for _, file := range files {
+		count += len(file.Comments)
+	}
+	return count
+}
@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	"sort"
+	"sync"
+	"walle/internal/comment"
+	"walle/internal/source"
+	"walle/internal/stats"
+)
+
+// StatsPipeline counts code and comment lines for every file in scope. Parse errors are printed as warnings,
+// files in languages walle doesn't parse are returned as skipped.
+func StatsPipeline(scanOpts *source.ScanOptions, pipeOpts Options) ([]stats.FileStats, []string, error) {
+	out := pipeOpts.renderer()
+	gitScanner := &source.GitScanner{}
+
+	files, err := gitScanner.GetFiles(*scanOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wg := &sync.WaitGroup{}
+	mu := &sync.Mutex{}
+
+	var results []stats.FileStats
+	var skipped, warnings []string
+	for _, file := range files {
+		wg.Add(1)
+
+		go func(file source.File) {
+			defer wg.Done()
+			commentScanner, err := comment.GetScanner(file.Path)
+			if err != nil {
+				mu.Lock()
+				skipped = append(skipped, file.Path)
+				mu.Unlock()
+				return
+			}
+
+			// Line classification needs every comment in the file, not only the ones in the diff
+			whole := file
+			whole.Status = source.StatusAdded
+			comments, err := commentScanner.Scan(whole)
+			if err != nil {
+				mu.Lock()
+				warnings = append(warnings, "⚠️  Parse error scanning "+file.Path+": "+err.Error())
+				mu.Unlock()
+				return
+			}
+
+			fileStats := stats.CountFile(file, comments)
+
+			mu.Lock()
+			results = append(results, fileStats)
+			mu.Unlock()
+		}(file)
+	}
+	wg.Wait()
+
+	sort.Strings(warnings)
+	for _, warning := range warnings {
+		out.Warning("%s", warning)
+	}
+	sort.Strings(skipped)
+	return results, skipped, nil
+}
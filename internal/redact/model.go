@@ -0,0 +1,49 @@
+package redact
+
+import "regexp"
+
+// ConfigFile is the configuration read from the repository root when no other file is given
+const ConfigFile = ".walle-redact.json"
+
+// DefaultPlaceholder replaces matches when neither the rule nor the config sets one
+const DefaultPlaceholder = "[REDACTED]"
+
+// Config lists what must not stay in comments
+type Config struct {
+	// Placeholder replaces matches of rules without their own
+	Placeholder string `json:"placeholder,omitempty"`
+	Rules       []Rule `json:"rules"`
+}
+
+// Rule matches a dictionary of words and regular expressions
+type Rule struct {
+	Name string `json:"name"`
+	// Words match case insensitively as whole words, such as codenames or employee names
+	Words    []string `json:"words,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+	// Placeholder replaces the matches of this rule
+	Placeholder string `json:"placeholder,omitempty"`
+	// Remove drops the whole comment instead of replacing the match
+	Remove bool `json:"remove,omitempty"`
+
+	compiled []*regexp.Regexp
+}
+
+// Action is what happened to a match
+type Action string
+
+const (
+	ActionReplaced Action = "replaced"
+	ActionRemoved  Action = "removed"
+)
+
+// Substitution is a line of the audit report
+type Substitution struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Rule string `json:"rule"`
+	// Match is the original text, the audit is not meant to be published with the code
+	Match       string `json:"match"`
+	Action      Action `json:"action"`
+	Replacement string `json:"replacement,omitempty"`
+}
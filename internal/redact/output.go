@@ -0,0 +1,43 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteTable prints the substitutions for a terminal
+func WriteTable(w io.Writer, substitutions []Substitution) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "location\trule\taction\tmatch\treplacement")
+	for _, s := range substitutions {
+		replacement := s.Replacement
+		if replacement == "" {
+			replacement = "-"
+		}
+		fmt.Fprintf(tw, "%s:%d\t%s\t%s\t%s\t%s\n", s.Path, s.Line, s.Rule, s.Action, oneLine(s.Match), replacement)
+	}
+	return tw.Flush()
+}
+
+// WriteAudit writes the substitutions to a JSON file
+func WriteAudit(path string, substitutions []Substitution) error {
+	if substitutions == nil {
+		substitutions = []Substitution{}
+	}
+	data, err := json.MarshalIndent(substitutions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode audit: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write audit: %w", err)
+	}
+	return nil
+}
+
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
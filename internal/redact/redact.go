@@ -0,0 +1,189 @@
+package redact
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"walle/internal/comment"
+)
+
+// LoadConfig reads and compiles a redaction config
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid redaction config %s: %w", path, err)
+	}
+	if cfg.Placeholder == "" {
+		cfg.Placeholder = DefaultPlaceholder
+	}
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if rule.Placeholder == "" {
+			rule.Placeholder = cfg.Placeholder
+		}
+		for _, word := range rule.Words {
+			if strings.TrimSpace(word) == "" {
+				continue
+			}
+			rule.compiled = append(rule.compiled, wordPattern(word))
+		}
+		for _, pattern := range rule.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redaction config %s: %s: %w", path, rule.Name, err)
+			}
+			rule.compiled = append(rule.compiled, re)
+		}
+	}
+	return &cfg, nil
+}
+
+// FindConfig loads path, or ConfigFile from root when path is empty
+func FindConfig(path, root string) (*Config, error) {
+	if path != "" {
+		return LoadConfig(path)
+	}
+	cfg, err := LoadConfig(filepath.Join(root, ConfigFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no redaction rules, create %s or pass --config", ConfigFile)
+	}
+	return cfg, err
+}
+
+// wordPattern matches word case insensitively, only at word boundaries where the word starts or ends
+// with a letter or digit
+func wordPattern(word string) *regexp.Regexp {
+	word = strings.TrimSpace(word)
+	pattern := regexp.QuoteMeta(word)
+	if isWordRune(rune(word[0])) {
+		pattern = `\b` + pattern
+	}
+	if isWordRune(rune(word[len(word)-1])) {
+		pattern += `\b`
+	}
+	return regexp.MustCompile(`(?i)` + pattern)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// match is a rule matching a range of a comment's text
+type match struct {
+	rule       *Rule
+	start, end int
+}
+
+// find returns the matches of every rule in the body of a comment, earlier and longer matches win
+// over overlapping ones. The markers are never matched so a rewritten comment stays a comment.
+func (cfg *Config) find(text string) []match {
+	bodyStart, bodyEnd := comment.BodyRange(text)
+	body := text[bodyStart:bodyEnd]
+
+	var all []match
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		for _, re := range rule.compiled {
+			for _, loc := range re.FindAllStringIndex(body, -1) {
+				if loc[0] < loc[1] {
+					all = append(all, match{rule: rule, start: bodyStart + loc[0], end: bodyStart + loc[1]})
+				}
+			}
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].start != all[j].start {
+			return all[i].start < all[j].start
+		}
+		return all[i].end > all[j].end
+	})
+
+	var matches []match
+	end := 0
+	for _, m := range all {
+		if m.start < end {
+			continue
+		}
+		matches = append(matches, m)
+		end = m.end
+	}
+	return matches
+}
+
+// Rewriter removes the comments a remove rule matches and replaces the matches of the other rules by
+// their placeholder. Comments with the keep marker are never removed, their matches are replaced.
+func (cfg *Config) Rewriter() *comment.Rewriter {
+	return comment.NewRewriter(cfg.removes, cfg.Redact)
+}
+
+// Redact replaces every match in the text of a comment by the placeholder of its rule
+func (cfg *Config) Redact(text string) string {
+	var sb strings.Builder
+	pos := 0
+	for _, m := range cfg.find(text) {
+		sb.WriteString(text[pos:m.start])
+		sb.WriteString(m.rule.Placeholder)
+		pos = m.end
+	}
+	sb.WriteString(text[pos:])
+	return sb.String()
+}
+
+// removes reports whether a remove rule matches the comment
+func (cfg *Config) removes(c *comment.Comment) bool {
+	for _, m := range cfg.find(c.Text) {
+		if m.rule.Remove {
+			return true
+		}
+	}
+	return false
+}
+
+// Audit lists the substitutions of the removed and rewritten comments
+func (cfg *Config) Audit(removals []comment.Comment, rewrites []comment.Rewrite) []Substitution {
+	var substitutions []Substitution
+	for _, c := range removals {
+		substitutions = append(substitutions, cfg.substitutions(c, ActionRemoved)...)
+	}
+	for _, r := range rewrites {
+		substitutions = append(substitutions, cfg.substitutions(r.Comment, ActionReplaced)...)
+	}
+	sort.SliceStable(substitutions, func(i, j int) bool {
+		if substitutions[i].Path != substitutions[j].Path {
+			return substitutions[i].Path < substitutions[j].Path
+		}
+		return substitutions[i].Line < substitutions[j].Line
+	})
+	return substitutions
+}
+
+func (cfg *Config) substitutions(c comment.Comment, action Action) []Substitution {
+	var substitutions []Substitution
+	for _, m := range cfg.find(c.Text) {
+		s := Substitution{
+			Path:   c.FilePath,
+			Line:   c.Line + strings.Count(c.Text[:m.start], "\n"),
+			Rule:   m.rule.Name,
+			Match:  c.Text[m.start:m.end],
+			Action: action,
+		}
+		if action == ActionReplaced {
+			s.Replacement = m.rule.Placeholder
+		}
+		substitutions = append(substitutions, s)
+	}
+	return substitutions
+}
@@ -0,0 +1,41 @@
+package render
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+type ColorMode string
+
+const (
+	ColorAuto   ColorMode = "auto"
+	ColorAlways ColorMode = "always"
+	ColorNever  ColorMode = "never"
+)
+
+// ParseColorMode validates a --color value
+func ParseColorMode(value string) (ColorMode, error) {
+	switch ColorMode(value) {
+	case ColorAuto, ColorAlways, ColorNever:
+		return ColorMode(value), nil
+	}
+	return "", fmt.Errorf("invalid color mode %q (expected auto, always or never)", value)
+}
+
+// applyColorMode sets the colour profile of a lipgloss renderer.
+// In auto mode NO_COLOR disables colours, otherwise lipgloss detects the terminal.
+func applyColorMode(lg *lipgloss.Renderer, mode ColorMode) {
+	switch mode {
+	case ColorAlways:
+		lg.SetColorProfile(termenv.ANSI256)
+	case ColorNever:
+		lg.SetColorProfile(termenv.Ascii)
+	default:
+		if os.Getenv("NO_COLOR") != "" {
+			lg.SetColorProfile(termenv.Ascii)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
+)
+
+// progressThreshold is the minimum number of files before a progress bar is shown
+const progressThreshold = 50
+
+const barWidth = 40
+
+// Progress shows a live progress bar on stderr, it is a no-op when stderr is not a terminal
+type Progress struct {
+	program *tea.Program
+	done    chan struct{}
+}
+
+type progressMsg string
+
+type stopMsg struct{}
+
+type progressModel struct {
+	total   int
+	current int
+	path    string
+	filled  lipgloss.Style
+	empty   lipgloss.Style
+	stopped bool
+}
+
+// StartProgress starts a progress bar for total files
+func (r *Renderer) StartProgress(total int) *Progress {
+	if total < progressThreshold || !isatty.IsTerminal(os.Stderr.Fd()) {
+		return &Progress{}
+	}
+
+	lg := lipgloss.NewRenderer(os.Stderr)
+	applyColorMode(lg, r.mode)
+
+	model := progressModel{
+		total:  total,
+		filled: lg.NewStyle().Foreground(lipgloss.Color("2")),
+		empty:  lg.NewStyle().Faint(true),
+	}
+
+	p := &Progress{
+		program: tea.NewProgram(model, tea.WithOutput(os.Stderr), tea.WithInput(nil)),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		_, _ = p.program.Run()
+		close(p.done)
+	}()
+	return p
+}
+
+// Increment marks one more file as scanned
+func (p *Progress) Increment(path string) {
+	if p.program == nil {
+		return
+	}
+	p.program.Send(progressMsg(path))
+}
+
+// Stop removes the progress bar and waits for it to finish drawing
+func (p *Progress) Stop() {
+	if p.program == nil {
+		return
+	}
+	p.program.Send(stopMsg{})
+	<-p.done
+}
+
+func (m progressModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case progressMsg:
+		m.current++
+		m.path = string(msg)
+	case stopMsg:
+		m.stopped = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m progressModel) View() string {
+	if m.stopped {
+		return ""
+	}
+
+	filled := barWidth * m.current / max(m.total, 1)
+	bar := m.filled.Render(strings.Repeat("█", filled)) + m.empty.Render(strings.Repeat("░", barWidth-filled))
+	return fmt.Sprintf("%s %d/%d %s", bar, m.current, m.total, m.path)
+}
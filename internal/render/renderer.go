@@ -0,0 +1,190 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"walle/internal/comment"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// contextLines is the number of code lines shown around a comment
+const contextLines = 2
+
+// Renderer prints human readable scan and fix output
+type Renderer struct {
+	out    io.Writer
+	lg     *lipgloss.Renderer
+	mode   ColorMode
+	styles styles
+}
+
+type styles struct {
+	header  lipgloss.Style
+	count   lipgloss.Style
+	gutter  lipgloss.Style
+	code    lipgloss.Style
+	warning lipgloss.Style
+	success lipgloss.Style
+	summary lipgloss.Style
+	added   lipgloss.Style
+	removed lipgloss.Style
+	hunk    lipgloss.Style
+	kinds   map[comment.Kind]lipgloss.Style
+}
+
+func New(out io.Writer, mode ColorMode) *Renderer {
+	lg := lipgloss.NewRenderer(out)
+	applyColorMode(lg, mode)
+
+	return &Renderer{
+		out:  out,
+		lg:   lg,
+		mode: mode,
+		styles: styles{
+			header:  lg.NewStyle().Bold(true).Foreground(lipgloss.Color("4")),
+			count:   lg.NewStyle().Faint(true),
+			gutter:  lg.NewStyle().Faint(true),
+			code:    lg.NewStyle(),
+			warning: lg.NewStyle().Foreground(lipgloss.Color("3")),
+			success: lg.NewStyle().Foreground(lipgloss.Color("2")),
+			summary: lg.NewStyle().Bold(true),
+			// Diff lines keep their tabs so they match the context lines and the real edit
+			added:   lg.NewStyle().Foreground(lipgloss.Color("2")).TabWidth(lipgloss.NoTabConversion),
+			removed: lg.NewStyle().Foreground(lipgloss.Color("1")).TabWidth(lipgloss.NoTabConversion),
+			hunk:    lg.NewStyle().Foreground(lipgloss.Color("6")).TabWidth(lipgloss.NoTabConversion),
+			kinds: map[comment.Kind]lipgloss.Style{
+				comment.KindLine:      lg.NewStyle().Foreground(lipgloss.Color("6")),
+				comment.KindBlock:     lg.NewStyle().Foreground(lipgloss.Color("5")),
+				comment.KindDoc:       lg.NewStyle().Foreground(lipgloss.Color("2")),
+				comment.KindDirective: lg.NewStyle().Foreground(lipgloss.Color("3")),
+			},
+		},
+	}
+}
+
+// Default returns a renderer on stdout that detects colour support
+func Default() *Renderer {
+	return New(os.Stdout, ColorAuto)
+}
+
+// Writer returns the writer the renderer prints to
+func (r *Renderer) Writer() io.Writer {
+	return r.out
+}
+
+// WithWriter returns a renderer with the same colour settings that prints to w
+func (r *Renderer) WithWriter(w io.Writer) *Renderer {
+	clone := *r
+	clone.out = w
+	return &clone
+}
+
+// NewStyle returns a lipgloss style bound to the renderer's colour profile
+func (r *Renderer) NewStyle() lipgloss.Style {
+	return r.lg.NewStyle()
+}
+
+// FileHeader prints the path of a file and the number of comments found in it
+func (r *Renderer) FileHeader(path string, count int) {
+	fmt.Fprintf(r.out, "%s %s\n", r.styles.header.Render(path), r.styles.count.Render(fmt.Sprintf("(%d comments)", count)))
+}
+
+// Snippet prints a comment with the surrounding code, the comment itself is coloured by kind
+func (r *Renderer) Snippet(content []byte, c comment.Comment) {
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	endLine := c.Line + strings.Count(c.Text, "\n")
+
+	first := max(c.Line-contextLines, 1)
+	last := min(endLine+contextLines, len(lines))
+	width := len(fmt.Sprint(last))
+
+	kindStyle := r.styles.kinds[c.Kind]
+	offset := 0
+	for i := 0; i < first-1; i++ {
+		offset += len(lines[i]) + 1
+	}
+
+	for n := first; n <= last; n++ {
+		line := lines[n-1]
+		lineStart, lineEnd := offset, offset+len(line)
+		offset = lineEnd + 1
+
+		start := clamp(int(c.StartByte), lineStart, lineEnd) - lineStart
+		end := clamp(int(c.EndByte), lineStart, lineEnd) - lineStart
+
+		marker := " "
+		if n >= c.Line && n <= endLine {
+			marker = kindStyle.Render("│")
+		}
+
+		text := paint(r.styles.code, line[:start]) + paint(kindStyle, line[start:end]) + paint(r.styles.code, line[end:])
+
+		fmt.Fprintf(r.out, "  %s %s %s\n", r.styles.gutter.Render(fmt.Sprintf("%*d", width, n)), marker, text)
+	}
+	footer := c.Kind.String()
+	if c.Redundancy > 0 {
+		footer += fmt.Sprintf(", redundancy %.2f", c.Redundancy)
+	}
+	fmt.Fprintf(r.out, "  %s\n", r.styles.gutter.Render(fmt.Sprintf("%*s %s", width, "", footer)))
+	for _, f := range c.Findings {
+		style := r.styles.warning
+		if f.Severity == comment.SeverityHigh {
+			style = r.styles.removed.Bold(true)
+		}
+		fmt.Fprintf(r.out, "  %*s %s\n", width, "", style.Render(fmt.Sprintf("%s: %s", f.Check, f.Message)))
+	}
+	fmt.Fprintln(r.out)
+}
+
+// Diff prints a unified diff with added and removed lines coloured
+func (r *Renderer) Diff(diff string) {
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		text := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "diff --git"), strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+			text = r.styles.summary.Render(text)
+		case strings.HasPrefix(line, "@@"):
+			text = r.styles.hunk.Render(text)
+		case strings.HasPrefix(line, "+"):
+			text = r.styles.added.Render(text)
+		case strings.HasPrefix(line, "-"):
+			text = r.styles.removed.Render(text)
+		}
+		fmt.Fprintln(r.out, text)
+	}
+}
+
+func (r *Renderer) Summary(format string, args ...any) {
+	fmt.Fprintln(r.out, r.styles.summary.Render(fmt.Sprintf(format, args...)))
+}
+
+func (r *Renderer) Success(format string, args ...any) {
+	fmt.Fprintln(r.out, r.styles.success.Render(fmt.Sprintf(format, args...)))
+}
+
+func (r *Renderer) Warning(format string, args ...any) {
+	fmt.Fprintln(r.out, r.styles.warning.Render(fmt.Sprintf(format, args...)))
+}
+
+func (r *Renderer) Info(format string, args ...any) {
+	fmt.Fprintf(r.out, format+"\n", args...)
+}
+
+// paint styles a piece of a line, empty pieces are skipped so no stray escape codes are printed
+func paint(style lipgloss.Style, text string) string {
+	text = strings.TrimRight(text, "\r")
+	if text == "" {
+		return ""
+	}
+	return style.Render(text)
+}
+
+func clamp(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
@@ -0,0 +1,36 @@
+package report
+
+// Report is the JSON document written by walle scan --format json
+type Report struct {
+	Version  int     `json:"version"`
+	Comments []Entry `json:"comments"`
+}
+
+// Entry is a single comment in a report, ID is what fix --from matches on
+type Entry struct {
+	ID     string `json:"id"`
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Kind   string `json:"kind"`
+	Symbol string `json:"symbol,omitempty"`
+	Text   string `json:"text"`
+	// Redundancy scores from 0 to 1 how much the comment only repeats its code
+	Redundancy float64 `json:"redundancy"`
+	// Findings are the problems checks found in the comment
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// Finding is a problem a check found in a comment
+type Finding struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Format selects how scan prints its results
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
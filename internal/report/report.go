@@ -0,0 +1,67 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"walle/internal/comment"
+)
+
+// version is the report format written by this release
+const version = 1
+
+// ParseFormat converts a --format value into a Format
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case FormatText, FormatJSON:
+		return Format(name), nil
+	}
+	return "", fmt.Errorf("unknown format %q (expected text or json)", name)
+}
+
+// New builds a report from scanned comments
+func New(comments []comment.Comment) Report {
+	r := Report{Version: version, Comments: []Entry{}}
+	for _, c := range comments {
+		var findings []Finding
+		for _, f := range c.Findings {
+			findings = append(findings, Finding{Check: f.Check, Severity: f.Severity.String(), Message: f.Message})
+		}
+		r.Comments = append(r.Comments, Entry{
+			ID:         c.ID,
+			Path:       c.FilePath,
+			Line:       c.Line,
+			Kind:       c.Kind.String(),
+			Symbol:     c.Symbol,
+			Text:       c.Text,
+			Redundancy: c.Redundancy,
+			Findings:   findings,
+		})
+	}
+	return r
+}
+
+// Write prints a report as indented JSON
+func Write(w io.Writer, r Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// Load reads a report written by Write
+func Load(path string) (Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Report{}, fmt.Errorf("invalid report %s: %w", path, err)
+	}
+	if r.Version > version {
+		return Report{}, fmt.Errorf("report %s was written by a newer version of walle", path)
+	}
+	return r, nil
+}
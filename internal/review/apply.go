@@ -0,0 +1,77 @@
+package review
+
+import (
+	"sort"
+	"walle/internal/comment"
+	"walle/internal/journal"
+	"walle/internal/render"
+)
+
+// Apply writes the review decisions to disk, each file is marked and trashed in a single edit.
+// The original content is journaled first so the review can be undone.
+func Apply(result Result, out *render.Renderer) error {
+	trash := groupByFile(result.Trash)
+	marks := groupByFile(result.MarkKeep)
+
+	var paths []string
+	for path := range trash {
+		paths = append(paths, path)
+	}
+	for path := range marks {
+		if _, ok := trash[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var edits []comment.FileEdit
+	for _, path := range paths {
+		var rewrites []comment.Rewrite
+		for _, c := range marks[path] {
+			rewrites = append(rewrites, comment.KeepRewrite(c))
+		}
+		edit, err := comment.PlanEdit(path, trash[path], rewrites)
+		if err != nil {
+			out.Warning("⚠️  Skipping %s: %v", path, err)
+			continue
+		}
+		edits = append(edits, edit)
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+
+	run, failed, err := journal.WriteAll(edits)
+	if err != nil && run.ID == "" {
+		return err
+	}
+	if err != nil {
+		out.Warning("⚠️  Error updating undo journal: %v", err)
+	}
+
+	removedCount, markedCount := 0, 0
+	for i, edit := range edits {
+		if failed[i] != nil {
+			out.Warning("⚠️  Error writing %s: %v", edit.Path, failed[i])
+			continue
+		}
+		removedCount += len(edit.Comments)
+		markedCount += len(edit.Rewrites)
+		out.Success("✅ Removed %d and marked %d comments in %s", len(edit.Comments), len(edit.Rewrites), edit.Path)
+	}
+
+	out.Info("")
+	out.Summary("🗑️  Trash compacted %d comments, %d marked walle:keep.", removedCount, markedCount)
+	if run.ID != "" {
+		out.Info("Undo with: walle undo %s", run.ID)
+	}
+	return nil
+}
+
+func groupByFile(comments []comment.Comment) map[string][]comment.Comment {
+	grouped := make(map[string][]comment.Comment)
+	for _, c := range comments {
+		grouped[c.FilePath] = append(grouped[c.FilePath], c)
+	}
+	return grouped
+}
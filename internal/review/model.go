@@ -0,0 +1,43 @@
+package review
+
+import "walle/internal/comment"
+
+type Decision int
+
+const (
+	Undecided Decision = iota
+	Keep
+	Trash
+	MarkKeep
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Keep:
+		return "keep"
+	case Trash:
+		return "trash"
+	case MarkKeep:
+		return "walle:keep"
+	}
+	return "undecided"
+}
+
+type Item struct {
+	Comment  comment.Comment
+	Decision Decision
+}
+
+// Filter limits which comments are shown in the review
+type Filter struct {
+	// Files are glob patterns matched against the path and the file name
+	Files []string
+	Kinds []comment.Kind
+}
+
+// Result holds the decisions made during a review
+type Result struct {
+	Trash    []comment.Comment
+	MarkKeep []comment.Comment
+	Aborted  bool
+}
@@ -0,0 +1,286 @@
+package review
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"walle/internal/comment"
+	"walle/internal/render"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type model struct {
+	items    []Item
+	visible  []int
+	cursor   int
+	contents map[string][]byte
+	out      *render.Renderer
+
+	// kindFilter cycles through all kinds, -1 shows every kind
+	kindFilter int
+	fileOnly   string
+
+	finished bool
+	aborted  bool
+
+	title    lipgloss.Style
+	faint    lipgloss.Style
+	keep     lipgloss.Style
+	trash    lipgloss.Style
+	markKeep lipgloss.Style
+}
+
+// Run opens the review TUI for the comments matching the filter
+func Run(comments []comment.Comment, filter Filter, out *render.Renderer) (Result, error) {
+	m := newModel(comments, filter, out)
+	if len(m.items) == 0 {
+		return Result{}, nil
+	}
+
+	final, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return final.(model).result(), nil
+}
+
+func newModel(comments []comment.Comment, filter Filter, out *render.Renderer) model {
+	m := model{
+		contents:   make(map[string][]byte),
+		out:        out,
+		kindFilter: -1,
+		title:      out.NewStyle().Bold(true),
+		faint:      out.NewStyle().Faint(true),
+		keep:       out.NewStyle().Foreground(lipgloss.Color("2")),
+		trash:      out.NewStyle().Foreground(lipgloss.Color("1")),
+		markKeep:   out.NewStyle().Foreground(lipgloss.Color("3")),
+	}
+
+	for _, c := range comments {
+		if !filter.matches(c) {
+			continue
+		}
+		if _, ok := m.contents[c.FilePath]; !ok {
+			content, err := os.ReadFile(c.FilePath)
+			if err != nil {
+				continue
+			}
+			m.contents[c.FilePath] = content
+		}
+		m.items = append(m.items, Item{Comment: c})
+	}
+
+	m.refilter()
+	return m
+}
+
+func (f Filter) matches(c comment.Comment) bool {
+	if len(f.Kinds) > 0 {
+		found := false
+		for _, kind := range f.Kinds {
+			if c.Kind == kind {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.Files) > 0 {
+		for _, pattern := range f.Files {
+			if ok, _ := filepath.Match(pattern, c.FilePath); ok {
+				return true
+			}
+			if ok, _ := filepath.Match(pattern, filepath.Base(c.FilePath)); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// refilter rebuilds the visible items from the in-TUI kind and file filters
+func (m *model) refilter() {
+	current := -1
+	if m.cursor < len(m.visible) {
+		current = m.visible[m.cursor]
+	}
+
+	m.visible = m.visible[:0]
+	m.cursor = 0
+	for i, item := range m.items {
+		if m.kindFilter >= 0 && item.Comment.Kind != comment.AllKinds[m.kindFilter] {
+			continue
+		}
+		if m.fileOnly != "" && item.Comment.FilePath != m.fileOnly {
+			continue
+		}
+		if i == current {
+			m.cursor = len(m.visible)
+		}
+		m.visible = append(m.visible, i)
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return nil
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch key.String() {
+	case "ctrl+c", "esc":
+		m.aborted = true
+		return m, tea.Quit
+	case "q", "enter":
+		m.finished = true
+		return m, tea.Quit
+	case "down", "j", "n", " ":
+		m.move(1)
+	case "up", "p":
+		m.move(-1)
+	case "k":
+		m.decide(Keep)
+	case "t", "d":
+		m.decide(Trash)
+	case "m":
+		m.decide(MarkKeep)
+	case "u":
+		m.decide(Undecided)
+	case "a":
+		m.trashFile()
+	case "tab":
+		m.kindFilter++
+		if m.kindFilter >= len(comment.AllKinds) {
+			m.kindFilter = -1
+		}
+		m.refilter()
+	case "f":
+		if m.fileOnly != "" {
+			m.fileOnly = ""
+		} else if item := m.current(); item != nil {
+			m.fileOnly = item.Comment.FilePath
+		}
+		m.refilter()
+	}
+	return m, nil
+}
+
+func (m *model) current() *Item {
+	if m.cursor >= len(m.visible) {
+		return nil
+	}
+	return &m.items[m.visible[m.cursor]]
+}
+
+func (m *model) move(delta int) {
+	m.cursor = min(max(m.cursor+delta, 0), max(len(m.visible)-1, 0))
+}
+
+func (m *model) decide(d Decision) {
+	item := m.current()
+	if item == nil {
+		return
+	}
+	item.Decision = d
+	if d != Undecided {
+		m.move(1)
+	}
+}
+
+func (m *model) trashFile() {
+	item := m.current()
+	if item == nil {
+		return
+	}
+	path := item.Comment.FilePath
+	for i := range m.items {
+		if m.items[i].Comment.FilePath == path {
+			m.items[i].Decision = Trash
+		}
+	}
+	// Jump past the trashed file
+	for m.cursor < len(m.visible)-1 && m.items[m.visible[m.cursor]].Comment.FilePath == path {
+		m.cursor++
+	}
+}
+
+func (m model) View() string {
+	if m.finished || m.aborted {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	kind := "all"
+	if m.kindFilter >= 0 {
+		kind = comment.AllKinds[m.kindFilter].String()
+	}
+	file := "all"
+	if m.fileOnly != "" {
+		file = m.fileOnly
+	}
+	sb.WriteString(m.title.Render(fmt.Sprintf("walle review  %d/%d", min(m.cursor+1, len(m.visible)), len(m.visible))))
+	sb.WriteString(m.faint.Render(fmt.Sprintf("  kind: %s  file: %s", kind, file)))
+	sb.WriteString("\n\n")
+
+	item := m.current()
+	if item == nil {
+		sb.WriteString("No comments match the current filter.\n")
+	} else {
+		sb.WriteString(m.title.Render(fmt.Sprintf("%s:%d", item.Comment.FilePath, item.Comment.Line)) + "\n")
+		snippet := m.out.WithWriter(&sb)
+		snippet.Snippet(m.contents[item.Comment.FilePath], item.Comment)
+		sb.WriteString("Decision: " + m.decisionStyle(item.Decision).Render(item.Decision.String()) + "\n\n")
+	}
+
+	counts := make(map[Decision]int)
+	for _, it := range m.items {
+		counts[it.Decision]++
+	}
+	sb.WriteString(m.faint.Render(fmt.Sprintf("keep %d · trash %d · walle:keep %d · undecided %d",
+		counts[Keep], counts[Trash], counts[MarkKeep], counts[Undecided])))
+	sb.WriteString("\n")
+	sb.WriteString(m.faint.Render("k keep · t trash · a trash file · m mark walle:keep · u undo · n/p next/prev · tab kind · f file · enter apply · esc abort"))
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+func (m model) decisionStyle(d Decision) lipgloss.Style {
+	switch d {
+	case Keep:
+		return m.keep
+	case Trash:
+		return m.trash
+	case MarkKeep:
+		return m.markKeep
+	}
+	return m.faint
+}
+
+func (m model) result() Result {
+	if m.aborted {
+		return Result{Aborted: true}
+	}
+
+	var result Result
+	for _, item := range m.items {
+		switch item.Decision {
+		case Trash:
+			result.Trash = append(result.Trash, item.Comment)
+		case MarkKeep:
+			result.MarkKeep = append(result.MarkKeep, item.Comment)
+		}
+	}
+	return result
+}
@@ -0,0 +1,149 @@
+package secret
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var rules = []rule{
+	{name: "AWS access key", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[0-9A-Z]{16}\b`)},
+	{name: "AWS secret key", pattern: regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|key).{0,20}?[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`), group: 1},
+	{name: "GCP API key", pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`)},
+	{name: "GCP service account key", pattern: regexp.MustCompile(`"private_key_id"\s*:\s*"([0-9a-f]{40})"`), group: 1},
+	{name: "Slack token", pattern: regexp.MustCompile(`\bxox[abposre]-[0-9A-Za-z\-]{10,}`)},
+	{name: "Slack webhook", pattern: regexp.MustCompile(`https://hooks\.slack\.com/services/[A-Za-z0-9/_\-]+`)},
+	{name: "GitHub token", pattern: regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}\b|\bgithub_pat_[A-Za-z0-9_]{22,}`)},
+	{name: "JSON web token", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{5,}\.eyJ[A-Za-z0-9_\-]{5,}\.[A-Za-z0-9_\-]{10,}`)},
+	{name: "private key", pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----(?s:.*?)(?:-----END [A-Z ]*PRIVATE KEY( BLOCK)?-----|$)`)},
+	{name: "password in connection string", pattern: regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.\-]*://[^\s:/@]+:([^\s@/]+)@`), group: 1},
+	{name: "password", pattern: regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret|api[_\-]?key|access[_\-]?token|auth[_\-]?token|client[_\-]?secret)\s*[:=]\s*["']?([^\s"',;]{6,})`), group: 1, value: credentialLike},
+}
+
+var (
+	// candidate is a run of characters that tokens and keys are made of
+	candidate = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{20,}`)
+	hexOnly   = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	uuid      = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// placeholders are values that stand in for a secret in documentation
+var placeholders = []string{"xxx", "***", "...", "<", "${", "{{", "%s", "changeme", "example", "your", "redacted", "placeholder", "dummy"}
+
+// Detect finds likely secrets in text: known key formats, assigned passwords and high entropy tokens.
+// It runs entirely offline.
+func Detect(text string) []Match {
+	var matches []Match
+	covered := func(start, end int) bool {
+		for _, m := range matches {
+			if start < m.End && m.Start < end {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, r := range rules {
+		for _, loc := range r.pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[2*r.group], loc[2*r.group+1]
+			if start < 0 || covered(start, end) {
+				continue
+			}
+			if r.group > 0 && isPlaceholder(text[start:end]) {
+				continue
+			}
+			if r.value != nil && !r.value(text[start:end]) {
+				continue
+			}
+			matches = append(matches, Match{Rule: r.name, Start: start, End: end})
+		}
+	}
+
+	for _, loc := range candidate.FindAllStringIndex(text, -1) {
+		token := text[loc[0]:loc[1]]
+		if covered(loc[0], loc[1]) || !highEntropy(token) {
+			continue
+		}
+		matches = append(matches, Match{Rule: "high entropy string", Start: loc[0], End: loc[1]})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Start < matches[j].Start
+	})
+	return matches
+}
+
+// Redact returns text with every match replaced by Placeholder
+func Redact(text string, matches []Match) string {
+	var sb strings.Builder
+	pos := 0
+	for _, m := range matches {
+		if m.Start < pos {
+			continue
+		}
+		sb.WriteString(text[pos:m.Start])
+		sb.WriteString(Placeholder)
+		pos = m.End
+	}
+	sb.WriteString(text[pos:])
+	return sb.String()
+}
+
+func isPlaceholder(value string) bool {
+	lower := strings.ToLower(value)
+	for _, p := range placeholders {
+		if strings.Contains(lower, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// credentialLike reports whether an assigned value mixes letters with digits or symbols, or is random
+// enough to be a key, so prose such as "password: must be long" is not taken for a password
+func credentialLike(value string) bool {
+	hasLetter := strings.IndexFunc(value, unicode.IsLetter) >= 0
+	hasOther := strings.IndexFunc(value, func(r rune) bool {
+		return unicode.IsDigit(r) || (unicode.IsPunct(r) || unicode.IsSymbol(r)) && !strings.ContainsRune("-_.", r)
+	}) >= 0
+	return hasLetter && hasOther || highEntropy(value)
+}
+
+// highEntropy reports whether a token looks random enough to be a key rather than a word, path or hash
+func highEntropy(token string) bool {
+	token = strings.Trim(token, "=-_")
+	if len(token) < 20 || uuid.MatchString(token) {
+		return false
+	}
+	hasDigit := strings.ContainsAny(token, "0123456789")
+	hasUpper := strings.ToLower(token) != token
+	hasLower := strings.ToUpper(token) != token
+	if hexOnly.MatchString(token) {
+		// Commit hashes and sha256 digests are quoted in comments all the time
+		if len(token) == 40 || len(token) == 64 || len(token) < 32 {
+			return false
+		}
+		return entropy(token) > 3.5
+	}
+	if !hasDigit || !hasUpper || !hasLower {
+		return false
+	}
+	return entropy(token) > 4.2
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var e float64
+	n := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / n
+		e -= p * math.Log2(p)
+	}
+	return e
+}
@@ -0,0 +1,20 @@
+package secret
+
+import "walle/internal/comment"
+
+// Annotate adds a high severity finding for every likely secret in a comment
+func Annotate(c *comment.Comment) {
+	matches := Detect(c.Text)
+	for _, m := range matches {
+		c.Findings = append(c.Findings, comment.Finding{
+			Check:    Check,
+			Severity: comment.SeverityHigh,
+			Message:  "possible " + m.Rule,
+		})
+	}
+}
+
+// RedactText replaces every secret in text by Placeholder
+func RedactText(text string) string {
+	return Redact(text, Detect(text))
+}
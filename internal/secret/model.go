@@ -0,0 +1,27 @@
+package secret
+
+import "regexp"
+
+// Check names the secret findings
+const Check = "secret"
+
+// Placeholder replaces a secret when it is redacted
+const Placeholder = "[REDACTED]"
+
+// Match is a likely secret in a comment
+type Match struct {
+	// Rule names what was found, such as "AWS access key"
+	Rule string
+	// Start and End are byte offsets of the secret in the text that was searched
+	Start int
+	End   int
+}
+
+// rule is a known secret format, group selects the part of the match that is the secret, 0 for all of it
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+	group   int
+	// value, when set, rejects secrets that don't look like a credential
+	value func(string) bool
+}
@@ -0,0 +1,56 @@
+package serve
+
+import (
+	"sync"
+	"walle/internal/comment"
+)
+
+// cache keeps the comments of scanned files keyed by path and content hash, the oldest entry is
+// evicted when it is full
+type cache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string][]comment.Comment
+	order   []string
+	hits    int
+	misses  int
+}
+
+func newCache(size int) *cache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &cache{size: size, entries: make(map[string][]comment.Comment)}
+}
+
+func (c *cache) get(key string) ([]comment.Comment, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	comments, ok := c.entries[key]
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return comments, ok
+}
+
+func (c *cache) put(key string, comments []comment.Comment) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	if len(c.order) >= c.size {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.entries[key] = comments
+	c.order = append(c.order, key)
+}
+
+func (c *cache) stats() (entries, hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries), c.hits, c.misses
+}
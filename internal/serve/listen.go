@@ -0,0 +1,55 @@
+package serve
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// Listen opens address, a localhost TCP address such as 127.0.0.1:7777 or the path of a unix socket.
+// A socket file left behind by a server that is gone is replaced, one in use is an error.
+func Listen(address string) (net.Listener, error) {
+	if host, _, err := net.SplitHostPort(address); err == nil && !strings.Contains(address, "/") {
+		if !isLoopback(host) {
+			return nil, fmt.Errorf("refusing to listen on %s, only localhost addresses are allowed", address)
+		}
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+		}
+		return listener, nil
+	}
+
+	if _, err := os.Stat(address); err == nil {
+		if conn, err := net.Dial("unix", address); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another server", address)
+		}
+		if err := os.Remove(address); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+	// Only the owner may ask the server to change files
+	if err := os.Chmod(address, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
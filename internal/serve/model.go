@@ -0,0 +1,63 @@
+package serve
+
+import "walle/internal/report"
+
+// DefaultCacheSize is the number of scanned files whose comments are kept in memory
+const DefaultCacheSize = 4096
+
+// ScanRequest asks for the comments of a file. Without Content the file is read from disk,
+// paths are relative to the directory the server was started in.
+type ScanRequest struct {
+	Path    string  `json:"path"`
+	Content *string `json:"content,omitempty"`
+	// All returns every comment instead of the comments on lines added relative to HEAD
+	All bool `json:"all,omitempty"`
+}
+
+// ScanResponse lists the comments of a file
+type ScanResponse struct {
+	Path     string         `json:"path"`
+	Comments []report.Entry `json:"comments"`
+	// Cached is set when the file was not parsed again
+	Cached bool `json:"cached"`
+}
+
+// FixRequest asks for a file without its comments. With Content the updated content is only returned,
+// without it the file on disk is changed when Write is set, journaled so walle undo restores it.
+type FixRequest struct {
+	Path    string  `json:"path"`
+	Content *string `json:"content,omitempty"`
+	All     bool    `json:"all,omitempty"`
+	// IDs limits the removal to these comment IDs, as reported by scan
+	IDs   []string `json:"ids,omitempty"`
+	Write bool     `json:"write,omitempty"`
+}
+
+// FixResponse is the content of a file without the removed comments
+type FixResponse struct {
+	Path    string   `json:"path"`
+	Content string   `json:"content"`
+	Removed int      `json:"removed"`
+	Repairs []Repair `json:"repairs,omitempty"`
+	Written bool     `json:"written"`
+	// Undo is the journal run that restores the file, set when it was written
+	Undo string `json:"undo,omitempty"`
+}
+
+// Repair is a change made around a removed comment so the file stays valid
+type Repair struct {
+	Line        int    `json:"line"`
+	Description string `json:"description"`
+}
+
+// HealthResponse reports that the server is up and how well its cache works
+type HealthResponse struct {
+	Status      string `json:"status"`
+	CachedFiles int    `json:"cached_files"`
+	Hits        int    `json:"hits"`
+	Misses      int    `json:"misses"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,313 @@
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"walle/internal/comment"
+	"walle/internal/journal"
+	"walle/internal/report"
+	"walle/internal/source"
+)
+
+// maxRequestSize limits request bodies, file content is sent inline
+const maxRequestSize = 64 << 20
+
+// Server answers scan and fix requests over HTTP. Parsers stay warm between requests and the
+// comments of every scanned file are cached by content, so repeated calls skip parsing.
+type Server struct {
+	// root is the directory the server was started in, requests cannot reach files outside it
+	root  string
+	cache *cache
+	log   *log.Logger
+	http  *http.Server
+}
+
+// NewServer creates a server for the files below root that caches up to cacheSize files, logs go to logs
+func NewServer(root string, cacheSize int, logs *log.Logger) (*Server, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+
+	s := &Server{root: root, cache: newCache(cacheSize), log: logs}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/scan", s.handleScan)
+	mux.HandleFunc("POST /v1/fix", s.handleFix)
+	mux.HandleFunc("GET /v1/health", s.handleHealth)
+	s.http = &http.Server{Handler: guard(mux), ReadHeaderTimeout: 10 * time.Second}
+	return s, nil
+}
+
+// guard rejects requests a web page in the browser could send: only loopback hosts are answered,
+// which stops DNS rebinding, and bodies must be JSON, which a page cannot send without a preflight
+func guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !isLoopback(strings.Trim(host, "[]")) {
+			reply(w, http.StatusForbidden, errorResponse{Error: "only localhost hosts are allowed"})
+			return
+		}
+		if r.Method == http.MethodPost {
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				reply(w, http.StatusUnsupportedMediaType, errorResponse{Error: "Content-Type must be application/json"})
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Serve answers requests on listener until Close is called
+func (s *Server) Serve(listener net.Listener) error {
+	err := s.http.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	return s.http.Close()
+}
+
+func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
+	var req ScanRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	path, err := s.resolve(req.Path)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	content, err := load(path, req.Content)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	comments, cached, err := s.comments(path, content, req.All)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	reply(w, http.StatusOK, ScanResponse{Path: req.Path, Comments: report.New(comments).Comments, Cached: cached})
+}
+
+func (s *Server) handleFix(w http.ResponseWriter, r *http.Request) {
+	var req FixRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Write && req.Content != nil {
+		reply(w, http.StatusBadRequest, errorResponse{Error: "write cannot be combined with content"})
+		return
+	}
+	path, err := s.resolve(req.Path)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	content, err := load(path, req.Content)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	comments, _, err := s.comments(path, content, req.All)
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	if len(req.IDs) > 0 {
+		comments = slices.DeleteFunc(slices.Clone(comments), func(c comment.Comment) bool {
+			return !slices.Contains(req.IDs, c.ID)
+		})
+	}
+
+	resp := FixResponse{Path: req.Path, Content: string(content)}
+	if len(comments) == 0 {
+		reply(w, http.StatusOK, resp)
+		return
+	}
+
+	var edit comment.FileEdit
+	if req.Write {
+		// PlanRemoval reads the file again and refuses it when it changed since the scan above
+		edit, err = comment.PlanRemoval(path, comments)
+	} else {
+		updated, repairs := comment.ApplyRemovals(path, content, comments)
+		edit = comment.FileEdit{Path: path, Original: content, Updated: updated, Comments: comments, Repairs: repairs}
+		err = comment.Verify(path, content, updated)
+	}
+	if err != nil {
+		s.fail(w, err)
+		return
+	}
+	if req.Write {
+		run, err := journal.Write(edit)
+		if err != nil {
+			s.fail(w, err)
+			return
+		}
+		resp.Written = true
+		resp.Undo = run.ID
+	}
+
+	resp.Content = string(edit.Updated)
+	resp.Removed = len(edit.Comments)
+	for _, repair := range edit.Repairs {
+		resp.Repairs = append(resp.Repairs, Repair{Line: repair.Line, Description: repair.Description})
+	}
+	reply(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	entries, hits, misses := s.cache.stats()
+	reply(w, http.StatusOK, HealthResponse{Status: "ok", CachedFiles: entries, Hits: hits, Misses: misses})
+}
+
+// comments returns the comments of a file, only those on lines added relative to HEAD unless all is set.
+// Every comment of the file is cached, the diff is applied afterwards since HEAD can move.
+func (s *Server) comments(path string, content []byte, all bool) ([]comment.Comment, bool, error) {
+	scanner, err := comment.GetScanner(path)
+	if err != nil {
+		return nil, false, &requestError{err}
+	}
+
+	key := path + "\x00" + journal.Hash(content)
+	comments, cached := s.cache.get(key)
+	if !cached {
+		comments, err = scanner.Scan(source.File{Path: path, Content: content, Status: source.StatusAdded})
+		if err != nil {
+			return nil, false, err
+		}
+		comments = comment.WithoutKept(comments)
+		s.cache.put(key, comments)
+	}
+	if all {
+		return comments, cached, nil
+	}
+
+	head, tracked, err := source.HeadFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s from HEAD: %w", path, err)
+	}
+	if !tracked {
+		return comments, cached, nil
+	}
+	ranges := source.AddedLineRanges(head, content)
+	var added []comment.Comment
+	for _, c := range comments {
+		for _, r := range ranges {
+			if c.Line >= r.Start && c.Line <= r.End {
+				added = append(added, c)
+				break
+			}
+		}
+	}
+	return added, cached, nil
+}
+
+// resolve returns the path of a request relative to the server root. Paths outside the root, also
+// through a symlink, are refused.
+func (s *Server) resolve(path string) (string, error) {
+	if path == "" {
+		return "", &requestError{errors.New("path is required")}
+	}
+	abs := path
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(s.root, abs)
+	}
+	abs = filepath.Clean(abs)
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(s.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &forbiddenError{path}
+	}
+	return rel, nil
+}
+
+// load returns the content sent with a request, or reads the file
+func load(path string, content *string) ([]byte, error) {
+	if content != nil {
+		return []byte(*content), nil
+	}
+	return os.ReadFile(path)
+}
+
+// forbiddenError is a path outside the directory the server was started in
+type forbiddenError struct {
+	path string
+}
+
+func (e *forbiddenError) Error() string {
+	return fmt.Sprintf("%s is outside the served directory", e.path)
+}
+
+// requestError is a problem with the request itself rather than with the file
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+// fail replies with the status that matches err
+func (s *Server) fail(w http.ResponseWriter, err error) {
+	var reqErr *requestError
+	var forbiddenErr *forbiddenError
+	var verifyErr *comment.VerifyError
+	status := http.StatusInternalServerError
+	switch {
+	case errors.As(err, &reqErr):
+		status = http.StatusBadRequest
+	case errors.As(err, &forbiddenErr):
+		status = http.StatusForbidden
+	case errors.Is(err, fs.ErrNotExist):
+		status = http.StatusNotFound
+	case errors.As(err, &verifyErr):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, comment.ErrModified):
+		status = http.StatusConflict
+	default:
+		s.log.Printf("%v", err)
+	}
+	reply(w, status, errorResponse{Error: err.Error()})
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		reply(w, http.StatusBadRequest, errorResponse{Error: "invalid request: " + strings.TrimPrefix(err.Error(), "json: ")})
+		return false
+	}
+	return true
+}
+
+func reply(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,62 @@
+package source
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// BlameLine is a line of a file in HEAD with the commit that last changed it
+type BlameLine struct {
+	Text   string
+	Author string
+	Date   time.Time
+}
+
+// Blamer blames files of the current repository at HEAD, opening the repository once
+type Blamer struct {
+	repo *git.Repository
+	root string
+}
+
+// NewBlamer opens the repository of the current directory
+func NewBlamer() (*Blamer, error) {
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, err
+	}
+	root, err := getRepoRoot(repo)
+	if err != nil {
+		return nil, err
+	}
+	return &Blamer{repo: repo, root: root}, nil
+}
+
+// Blame returns the lines of path in HEAD, tracked is false when HEAD does not have the file
+func (b *Blamer) Blame(path string) (lines []BlameLine, tracked bool, err error) {
+	relPath, err := repoRelativePath(b.root, path)
+	if err != nil {
+		return nil, false, err
+	}
+	head, err := b.repo.Head()
+	if err != nil {
+		return nil, false, nil
+	}
+	commit, err := b.repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get head commit: %w", err)
+	}
+	if _, err := commit.File(relPath); err != nil {
+		return nil, false, nil
+	}
+
+	result, err := git.Blame(commit, relPath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to blame %s: %w", path, err)
+	}
+	for _, line := range result.Lines {
+		lines = append(lines, BlameLine{Text: line.Text, Author: line.AuthorName, Date: line.Date})
+	}
+	return lines, true, nil
+}
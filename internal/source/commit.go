@@ -0,0 +1,165 @@
+package source
+
+import (
+	"fmt"
+	"net/mail"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+type CommitOptions struct {
+	// Files are staged before committing, nothing else may be staged
+	Files   []string
+	Message string
+	// Author in "Name <email>" form, empty uses the git config
+	Author string
+	Amend  bool
+}
+
+// Commit stages exactly the given files and creates a commit
+func Commit(opts CommitOptions) (plumbing.Hash, error) {
+	repo, err := OpenRepository()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	files, err := checkStaged(repo, opts.Files)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	for path := range files {
+		if _, err := worktree.Add(path); err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+	}
+
+	commitOpts := &git.CommitOptions{Amend: opts.Amend}
+	if opts.Author != "" {
+		author, err := parseSignature(opts.Author)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		commitOpts.Author = author
+	} else if opts.Amend {
+		// Amending keeps the original author, like git does
+		head, err := repo.Head()
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to get HEAD: %w", err)
+		}
+		headCommit, err := repo.CommitObject(head.Hash())
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to get HEAD commit: %w", err)
+		}
+		commitOpts.Author = &headCommit.Author
+	}
+
+	hash, err := worktree.Commit(opts.Message, commitOpts)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to commit: %w", err)
+	}
+	return hash, nil
+}
+
+// CheckStaged fails when anything other than the given files is staged, so Commit won't refuse them
+// after they were written
+func CheckStaged(files []string) error {
+	repo, err := OpenRepository()
+	if err != nil {
+		return err
+	}
+	_, err = checkStaged(repo, files)
+	return err
+}
+
+// checkStaged returns the repo-relative paths of files, or an error when anything else is staged
+func checkStaged(repo *git.Repository, paths []string) (map[string]bool, error) {
+	root, err := getRepoRoot(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]bool)
+	for _, file := range paths {
+		relPath, err := repoRelativePath(root, file)
+		if err != nil {
+			return nil, err
+		}
+		files[relPath] = true
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	for path, fileStatus := range status {
+		staged := fileStatus.Staging != git.Unmodified && fileStatus.Staging != git.Untracked
+		if staged && !files[path] {
+			return nil, fmt.Errorf("%s is already staged, commit or unstage it first", path)
+		}
+	}
+	return files, nil
+}
+
+// CommitSubject returns the first line of the message of a revision, used for fixup commits
+func CommitSubject(rev string) (string, error) {
+	message, err := CommitMessage(rev)
+	if err != nil {
+		return "", err
+	}
+	subject, _, _ := strings.Cut(message, "\n")
+	return subject, nil
+}
+
+// CommitMessage returns the full message of a revision
+func CommitMessage(rev string) (string, error) {
+	repo, err := OpenRepository()
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to get commit %s: %w", rev, err)
+	}
+
+	return commit.Message, nil
+}
+
+func repoRelativePath(root, path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	relPath, err := filepath.Rel(root, absPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(relPath), nil
+}
+
+func parseSignature(value string) (*object.Signature, error) {
+	address, err := mail.ParseAddress(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid author %q (expected \"Name <email>\"): %w", value, err)
+	}
+	return &object.Signature{Name: address.Name, Email: address.Address, When: time.Now()}, nil
+}
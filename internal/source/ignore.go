@@ -0,0 +1,43 @@
+package source
+
+import (
+	"path/filepath"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+// IgnoreMatcher applies the .gitignore rules of the repository to paths relative to the current directory
+type IgnoreMatcher struct {
+	root string
+	gi   *gitignore.GitIgnore
+}
+
+// NewIgnoreMatcher loads the .gitignore of the current repository, outside a repository nothing is ignored
+func NewIgnoreMatcher() *IgnoreMatcher {
+	root, err := RepoRoot()
+	if err != nil {
+		return &IgnoreMatcher{}
+	}
+	return &IgnoreMatcher{root: root, gi: loadGitIgnore(root)}
+}
+
+// Ignored reports whether path is excluded, the .git directory always is
+func (m *IgnoreMatcher) Ignored(path string) bool {
+	if filepath.Base(path) == ".git" {
+		return true
+	}
+	if m.gi == nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	relPath, err := filepath.Rel(m.root, absPath)
+	if err != nil || relPath == "." {
+		return false
+	}
+	// Patterns such as build/ only match directories with the trailing slash
+	relPath = filepath.ToSlash(relPath)
+	return m.gi.MatchesPath(relPath) || m.gi.MatchesPath(relPath+"/")
+}
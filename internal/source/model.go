@@ -17,6 +17,8 @@ type ScanOptions struct {
 
 	IncludeUntracked bool
 	IgnoreGitIgnore  bool
+	// Staged reads SpecificFiles from the index instead of the worktree, as they will be committed
+	Staged bool
 }
 
 type File struct {
@@ -0,0 +1,102 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// BuildScanOptions turns the -a, -p, --ignore-gitignore, --base and --target flags and the file arguments
+// the commands share into scan options
+func BuildScanOptions(all bool, path string, ignoreGitIgnore bool, baseCommit, targetCommit string, args []string) (*ScanOptions, error) {
+	scanOpts := &ScanOptions{
+		BaseCommit:   baseCommit,
+		TargetCommit: targetCommit,
+	}
+
+	if len(args) > 0 {
+		if path != "" || baseCommit != "" || targetCommit != "" {
+			return nil, errors.New("file arguments cannot be combined with --path, --base or --target")
+		}
+		hasDir := false
+		for _, arg := range args {
+			info, err := os.Stat(arg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find path: %w", err)
+			}
+			if !info.IsDir() {
+				scanOpts.SpecificFiles = append(scanOpts.SpecificFiles, arg)
+				continue
+			}
+			files, err := ListFiles(arg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list files: %w", err)
+			}
+			scanOpts.SpecificFiles = append(scanOpts.SpecificFiles, files...)
+			hasDir = true
+		}
+		// Only new comments in the files, like the default scan, unless -a asks for everything in them
+		scanOpts.Type = ScanDiff
+		if all {
+			scanOpts.Type = ScanWhole
+		}
+		// Bypass gitignore when only files are named, like -p does
+		scanOpts.IgnoreGitIgnore = !hasDir
+	} else if path != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find path: %w", err)
+		}
+
+		if info.IsDir() {
+			files, err := ListFiles(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list files: %w", err)
+			}
+			scanOpts.SpecificFiles = files
+			// Respect gitignore when scanning a directory
+		} else {
+			scanOpts.SpecificFiles = []string{path}
+			// Bypass gitignore when scanning a specific file
+			scanOpts.IgnoreGitIgnore = true
+		}
+		scanOpts.Type = ScanWhole
+	} else if all {
+		files, err := ListFiles(".")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files: %w", err)
+		}
+		scanOpts.SpecificFiles = files
+		scanOpts.Type = ScanWhole
+		// Respect gitignore by default when using -a flag
+	} else {
+		scanOpts.Type = ScanDiff
+		// Default: respect gitignore
+	}
+
+	// Override gitignore if flag is set
+	if ignoreGitIgnore {
+		scanOpts.IgnoreGitIgnore = true
+	}
+
+	return scanOpts, nil
+}
+
+// ListFiles returns the files below root, leaving out the .git directory
+func ListFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
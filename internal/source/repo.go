@@ -0,0 +1,191 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// OpenRepository opens the git repository containing the current directory
+func OpenRepository() (*git.Repository, error) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := git.PlainOpenWithOptions(currentDir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, errors.New("no source repository found (are you in a source dir?)")
+	}
+	return repo, nil
+}
+
+// GitDir returns the path of the .git directory of the current repository
+func GitDir() (string, error) {
+	repo, err := OpenRepository()
+	if err != nil {
+		return "", err
+	}
+
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return "", errors.New("repository is not stored on disk")
+	}
+	return storage.Filesystem().Root(), nil
+}
+
+// RepoRoot returns the root of the worktree of the current repository
+func RepoRoot() (string, error) {
+	repo, err := OpenRepository()
+	if err != nil {
+		return "", err
+	}
+	return getRepoRoot(repo)
+}
+
+var (
+	rootsMu sync.Mutex
+	// roots caches RepoRoot by working directory, RepoPath is called for every scanned file
+	roots = make(map[string]string)
+)
+
+// RepoPath returns path relative to the root of the current repository, with forward slashes.
+// Outside a repository it returns the cleaned path.
+func RepoPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return filepath.ToSlash(filepath.Clean(path))
+	}
+
+	rootsMu.Lock()
+	root, ok := roots[cwd]
+	if !ok {
+		root, _ = RepoRoot()
+		roots[cwd] = root
+	}
+	rootsMu.Unlock()
+
+	if root == "" {
+		return filepath.ToSlash(filepath.Clean(path))
+	}
+	relPath, err := repoRelativePath(root, path)
+	if err != nil {
+		return filepath.ToSlash(filepath.Clean(path))
+	}
+	return relPath
+}
+
+// StagedFiles returns the files added or modified in the index, relative to the current directory
+func StagedFiles() ([]string, error) {
+	repo, err := OpenRepository()
+	if err != nil {
+		return nil, err
+	}
+	root, err := getRepoRoot(repo)
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for path, fileStatus := range status {
+		switch fileStatus.Staging {
+		case git.Added, git.Modified, git.Copied, git.Renamed:
+		default:
+			continue
+		}
+		if fileStatus.Worktree == git.Deleted {
+			continue
+		}
+		relPath, err := filepath.Rel(cwd, filepath.Join(root, filepath.FromSlash(path)))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, relPath)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// indexFile returns the content of a file as it is staged, treePath is its path from the repository root
+func indexFile(repo *git.Repository, treePath string) ([]byte, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	entry, err := idx.Entry(filepath.ToSlash(treePath))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not staged: %w", treePath, err)
+	}
+	blob, err := repo.BlobObject(entry.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staged %s: %w", treePath, err)
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staged %s: %w", treePath, err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// HeadFile returns the content of a file in HEAD of the repository containing it, tracked is false
+// when there is no repository, no HEAD or HEAD does not have the file
+func HeadFile(path string) (content []byte, tracked bool, err error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, false, err
+	}
+	repo, err := git.PlainOpenWithOptions(filepath.Dir(absPath), &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, false, nil
+	}
+	root, err := getRepoRoot(repo)
+	if err != nil {
+		return nil, false, nil
+	}
+	relPath, err := repoRelativePath(root, absPath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, false, nil
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get head commit: %w", err)
+	}
+	headTree, err := headCommit.Tree()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get head tree: %w", err)
+	}
+	headFile, err := headTree.File(relPath)
+	if err != nil {
+		return nil, false, nil
+	}
+	text, err := headFile.Contents()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read head file content: %w", err)
+	}
+	return []byte(text), true, nil
+}
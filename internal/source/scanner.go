@@ -70,12 +70,9 @@ func (g *GitScanner) getSpecificFiles(opts ScanOptions) ([]File, error) {
 	}
 
 	var gi *gitignore.GitIgnore
-	var repoRoot string
-	if !opts.IgnoreGitIgnore {
-		repoRoot, err = getRepoRoot(repo)
-		if err == nil {
-			gi = loadGitIgnore(repoRoot)
-		}
+	repoRoot, rootErr := getRepoRoot(repo)
+	if !opts.IgnoreGitIgnore && rootErr == nil {
+		gi = loadGitIgnore(repoRoot)
 	}
 
 	var files []File
@@ -95,7 +92,21 @@ func (g *GitScanner) getSpecificFiles(opts ScanOptions) ([]File, error) {
 			}
 		}
 
-		content, err := os.ReadFile(filePath)
+		// HEAD and the index are looked up by the path from the repository root, the file may be given
+		// relative to a subdirectory
+		treePath := filePath
+		if rootErr == nil {
+			if relPath, err := repoRelativePath(repoRoot, filePath); err == nil {
+				treePath = relPath
+			}
+		}
+
+		var content []byte
+		if opts.Staged {
+			content, err = indexFile(repo, treePath)
+		} else {
+			content, err = os.ReadFile(filePath)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
 		}
@@ -108,11 +119,15 @@ func (g *GitScanner) getSpecificFiles(opts ScanOptions) ([]File, error) {
 		if opts.Type == ScanWhole {
 			file.Status = StatusAdded
 		} else {
-			file.Status = StatusModified
-			diffRanges, err := g.getAddedLineRanges(repo, filePath)
+			diffRanges, tracked, err := g.getAddedLineRanges(repo, content, treePath)
 			if err != nil {
 				return nil, fmt.Errorf("failed to get diff ranges for %s: %w", filePath, err)
 			}
+			file.Status = StatusModified
+			if !tracked {
+				// Every line of a file that is not in HEAD is new
+				file.Status = StatusAdded
+			}
 			file.DiffRanges = diffRanges
 		}
 
@@ -225,11 +240,13 @@ func (g *GitScanner) getCommitDiff(opts ScanOptions) ([]File, error) {
 			continue
 		}
 
-		if gi != nil && gi.MatchesPath(toFile.Name) {
+		// The name of a tree file is only its base name, the change has the path from the root
+		path := change.To.Name
+		if gi != nil && gi.MatchesPath(path) {
 			continue
 		}
 
-		file, err := g.processTreeFile(toFile, action, baseTree, opts.Type)
+		file, err := g.processTreeFile(path, toFile, action, baseTree, opts.Type)
 		if err != nil {
 			return nil, err
 		}
@@ -242,13 +259,13 @@ func (g *GitScanner) getCommitDiff(opts ScanOptions) ([]File, error) {
 	return files, nil
 }
 
-func (g *GitScanner) processTreeFile(toFile *object.File, action merkletrie.Action, baseTree *object.Tree, scanType ScanType) (*File, error) {
-	if !isSupportedFile(toFile.Name) {
+func (g *GitScanner) processTreeFile(path string, toFile *object.File, action merkletrie.Action, baseTree *object.Tree, scanType ScanType) (*File, error) {
+	if !isSupportedFile(path) {
 		return nil, nil
 	}
 
 	file := &File{
-		Path: toFile.Name,
+		Path: path,
 	}
 
 	switch action {
@@ -262,14 +279,14 @@ func (g *GitScanner) processTreeFile(toFile *object.File, action merkletrie.Acti
 
 	content, err := toFile.Contents()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %w", toFile.Name, err)
+		return nil, fmt.Errorf("failed to read file %s: %w", path, err)
 	}
 	file.Content = []byte(content)
 
 	if scanType == ScanDiff {
 		var oldContent string
 		if baseTree != nil {
-			baseFile, err := baseTree.File(toFile.Name)
+			baseFile, err := baseTree.File(path)
 			if err == nil {
 				oldContent, _ = baseFile.Contents()
 			}
@@ -357,7 +374,7 @@ func (g *GitScanner) getWorkingTreeChanges(opts ScanOptions) ([]File, error) {
 		file.Content = content
 
 		if opts.Type == ScanDiff {
-			diffRanges, err := g.getAddedLineRanges(repo, filePath)
+			diffRanges, _, err := g.getAddedLineRanges(repo, content, filePath)
 			if err != nil {
 				return nil, fmt.Errorf("failed to get diff ranges for %s: %w", filePath, err)
 			}
@@ -370,44 +387,59 @@ func (g *GitScanner) getWorkingTreeChanges(opts ScanOptions) ([]File, error) {
 	return files, nil
 }
 
-func (g *GitScanner) getAddedLineRanges(repo *git.Repository, filePath string) ([]LineRange, error) {
+// getAddedLineRanges returns the lines of content that are not in HEAD, treePath is the path of the file
+// in the HEAD tree. tracked is false when HEAD does not have the file.
+func (g *GitScanner) getAddedLineRanges(repo *git.Repository, content []byte, treePath string) ([]LineRange, bool, error) {
 	head, err := repo.Head()
 	if err != nil {
-		return nil, nil
+		return nil, false, nil
 	}
 
 	headCommit, err := repo.CommitObject(head.Hash())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get head commit: %w", err)
+		return nil, false, fmt.Errorf("failed to get head commit: %w", err)
 	}
 
 	headTree, err := headCommit.Tree()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get head tree: %w", err)
+		return nil, false, fmt.Errorf("failed to get head tree: %w", err)
 	}
 
-	headFile, err := headTree.File(filePath)
+	headFile, err := headTree.File(treePath)
 	if err != nil {
-		return nil, nil
+		return nil, false, nil
 	}
 
 	headContent, err := headFile.Contents()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read head file content: %w", err)
+		return nil, false, fmt.Errorf("failed to read head file content: %w", err)
 	}
 
-	currentContent, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read current file: %w", err)
-	}
+	return calculateAddedRanges(headContent, string(content)), true, nil
+}
 
-	return calculateAddedRanges(headContent, string(currentContent)), nil
+// AddedLineRanges returns the lines of newContent that are not in oldContent
+func AddedLineRanges(oldContent, newContent []byte) []LineRange {
+	return calculateAddedRanges(string(oldContent), string(newContent))
 }
 
 func calculateAddedRanges(oldContent, newContent string) []LineRange {
 	oldLines := splitLines(oldContent)
 	newLines := splitLines(newContent)
 
+	// Lines shared at the start and end are unchanged, only the middle needs the quadratic LCS
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+	oldLines = oldLines[prefix : len(oldLines)-suffix]
+	newLines = newLines[prefix : len(newLines)-suffix]
+
 	lcs := computeLCS(oldLines, newLines)
 
 	var ranges []LineRange
@@ -415,7 +447,7 @@ func calculateAddedRanges(oldContent, newContent string) []LineRange {
 
 	lcsIndex := 0
 	for newLineNum, newLine := range newLines {
-		lineNum := newLineNum + 1
+		lineNum := prefix + newLineNum + 1
 
 		if lcsIndex < len(lcs) && newLine == lcs[lcsIndex] {
 			if currentRange != nil {
@@ -0,0 +1,23 @@
+package stale
+
+// Check names the stale reference findings
+const Check = "stale"
+
+// RefKind tells what a reference in a comment points to
+type RefKind int
+
+const (
+	// RefSymbol is an identifier such as parseConfig, parse_config() or `x`
+	RefSymbol RefKind = iota
+	// RefPath is a file path such as internal/config.go or settings.yaml
+	RefPath
+)
+
+// Reference is something a comment mentions that should exist in the code or the repository
+type Reference struct {
+	Kind RefKind
+	// Text is the reference as written, without surrounding punctuation
+	Text string
+	// Name is what is looked up, the last segment of a dotted symbol such as Server.Start
+	Name string
+}
@@ -0,0 +1,129 @@
+package stale
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"walle/internal/comment"
+	"walle/internal/languages"
+)
+
+var (
+	camelCase  = regexp.MustCompile(`^[a-z][a-z0-9]*[A-Z][A-Za-z0-9]*$`)
+	pascalCase = regexp.MustCompile(`^[A-Z][a-z0-9]+[A-Z][A-Za-z0-9]*$`)
+	snakeCase  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(_[A-Za-z0-9]+)+$`)
+	identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	fileName   = regexp.MustCompile(`^[A-Za-z0-9_.\-]+\.([A-Za-z0-9]{1,6})$`)
+)
+
+// fileExtensions are extensions of files that are not source code but are often named in comments
+var fileExtensions = map[string]bool{
+	".md": true, ".json": true, ".yaml": true, ".yml": true, ".toml": true, ".txt": true, ".ini": true,
+	".cfg": true, ".conf": true, ".xml": true, ".csv": true, ".sql": true, ".mod": true, ".sum": true,
+	".lock": true, ".env": true, ".proto": true, ".tmpl": true, ".properties": true,
+}
+
+// productNames are camel cased words that name products, not code
+var productNames = map[string]bool{
+	"GitHub": true, "GitLab": true, "JavaScript": true, "TypeScript": true, "PostgreSQL": true,
+	"MySQL": true, "MongoDB": true, "YouTube": true, "PowerShell": true, "WebSocket": true,
+	"WebSockets": true, "OAuth": true, "LaTeX": true, "DevOps": true, "OpenAPI": true, "GraphQL": true,
+	"macOS": true, "iOS": true, "iPhone": true, "iPad": true, "eBay": true, "npm": true,
+}
+
+// linkingVerbs follow a single letter that names a variable, as in "x is always non-nil"
+var linkingVerbs = map[string]bool{
+	"is": true, "are": true, "was": true, "must": true, "should": true, "can": true, "will": true,
+	"has": true, "may": true, "cannot": true,
+}
+
+// References extracts the identifiers and file paths a comment mentions. Only words that look like
+// code count: camelCase, PascalCase and snake_case names, calls such as parse(), names in backticks,
+// single letters used as a variable and paths with a file extension.
+func References(text string) []Reference {
+	words := strings.Fields(comment.StripMarkers(text))
+	var refs []Reference
+	seen := make(map[string]bool)
+	add := func(ref Reference) {
+		if !seen[ref.Text] {
+			seen[ref.Text] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	for i, word := range words {
+		if strings.Contains(word, "://") || strings.HasPrefix(word, "@") || strings.Contains(word, "@") {
+			continue
+		}
+		quoted := strings.HasPrefix(word, "`") && strings.HasSuffix(strings.TrimRight(word, ".,;:!?)"), "`")
+		token := strings.Trim(word, "`'\"()[]{}<>,;:!?*")
+		token = strings.TrimSuffix(token, ".")
+		call := strings.HasSuffix(token, "()")
+		if i := strings.Index(token, "("); i > 0 {
+			token = token[:i]
+		}
+		if token == "" {
+			continue
+		}
+
+		if ref, ok := pathReference(token); ok {
+			add(ref)
+			continue
+		}
+
+		segments := strings.Split(token, ".")
+		if !allIdentifiers(segments) {
+			continue
+		}
+		name := segments[len(segments)-1]
+		dotted := len(segments) > 1
+		switch {
+		case quoted || call || dotted:
+		case productNames[token]:
+			continue
+		case camelCase.MatchString(token), pascalCase.MatchString(token), snakeCase.MatchString(token):
+		case len(token) == 1 && token != "a" && token != "I" && token != "i" &&
+			i+1 < len(words) && linkingVerbs[strings.ToLower(words[i+1])]:
+		default:
+			continue
+		}
+		// Dotted prose such as "e.g" or "i.e" is not code
+		if dotted && len(name) < 2 {
+			continue
+		}
+		add(Reference{Kind: RefSymbol, Text: token, Name: name})
+	}
+	return refs
+}
+
+// pathReference recognizes file paths: a/b/c.go, ./config.yaml, or a bare file name with a known extension
+func pathReference(token string) (Reference, bool) {
+	token = strings.TrimPrefix(token, "./")
+	base := token
+	if i := strings.LastIndex(token, "/"); i >= 0 {
+		base = token[i+1:]
+	}
+	if !fileName.MatchString(base) {
+		return Reference{}, false
+	}
+	ext := strings.ToLower(filepath.Ext(base))
+	known := fileExtensions[ext] || languages.IsSupportedExtension(ext)
+	// A slash makes a path of any extension, a bare name must have a known one
+	if !known && !strings.Contains(token, "/") {
+		return Reference{}, false
+	}
+	// Bare names with a code extension such as main.go are paths, version numbers such as 1.2 are not
+	if strings.Trim(strings.TrimSuffix(base, ext), "0123456789.") == "" {
+		return Reference{}, false
+	}
+	return Reference{Kind: RefPath, Text: token, Name: token}, true
+}
+
+func allIdentifiers(segments []string) bool {
+	for _, s := range segments {
+		if !identifier.MatchString(s) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package stale
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"walle/internal/comment"
+	"walle/internal/languages"
+	"walle/internal/source"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Resolver looks up the references of comments in the code of their package and the files of the
+// repository. Packages are parsed once and shared between comments, it is safe for concurrent use.
+type Resolver struct {
+	root string
+
+	mu       sync.Mutex
+	packages map[string]map[string]bool
+	files    map[string]bool
+	names    map[string]bool
+}
+
+// NewResolver resolves paths against the repository at root
+func NewResolver(root string) *Resolver {
+	return &Resolver{root: root, packages: make(map[string]map[string]bool)}
+}
+
+// Unresolved returns the references of a comment that name nothing in its package or repository
+func (r *Resolver) Unresolved(c comment.Comment) []Reference {
+	if c.Kind == comment.KindDirective {
+		return nil
+	}
+	var unresolved []Reference
+	for _, ref := range References(c.Text) {
+		if !r.resolves(c.FilePath, ref) {
+			unresolved = append(unresolved, ref)
+		}
+	}
+	return unresolved
+}
+
+// Filter adds a stale finding for every unresolved reference, with only set it keeps just the comments
+// that have one
+func (r *Resolver) Filter(only bool) func(*comment.Comment) bool {
+	return func(c *comment.Comment) bool {
+		unresolved := r.Unresolved(*c)
+		for _, ref := range unresolved {
+			c.Findings = append(c.Findings, comment.Finding{Check: Check, Severity: comment.SeverityMedium, Message: message(ref)})
+		}
+		return !only || len(unresolved) > 0
+	}
+}
+
+func message(ref Reference) string {
+	if ref.Kind == RefPath {
+		return fmt.Sprintf("refers to %s, which does not exist", ref.Text)
+	}
+	return fmt.Sprintf("refers to %s, which is not declared or used in this package", ref.Text)
+}
+
+func (r *Resolver) resolves(filePath string, ref Reference) bool {
+	if ref.Kind == RefPath {
+		return r.fileExists(filePath, ref.Name)
+	}
+	return r.symbols(filePath)[ref.Name]
+}
+
+// symbols returns the identifiers of every file in the directory of filePath written in the same
+// language, which is the package for most languages
+func (r *Resolver) symbols(filePath string) map[string]bool {
+	dir := filepath.Dir(filePath)
+	lang := languages.GetLanguageNameForExtension(strings.ToLower(filepath.Ext(filePath)))
+	key := dir + "\x00" + lang
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if symbols, ok := r.packages[key]; ok {
+		return symbols
+	}
+
+	symbols := make(map[string]bool)
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || languages.GetLanguageNameForExtension(strings.ToLower(filepath.Ext(path))) != lang {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		tree, err := comment.ParseFile(path, content)
+		if err != nil {
+			continue
+		}
+		collectIdentifiers(tree.RootNode(), content, symbols)
+		tree.Close()
+	}
+	r.packages[key] = symbols
+	return symbols
+}
+
+// collectIdentifiers adds the named leaves of the tree that look like identifiers, declared or used
+func collectIdentifiers(node *sitter.Node, content []byte, symbols map[string]bool) {
+	if comment.IsCommentNode(node) {
+		return
+	}
+	if node.ChildCount() == 0 {
+		if node.IsNamed() {
+			if text := node.Content(content); identifier.MatchString(text) {
+				symbols[text] = true
+			}
+		}
+		return
+	}
+	for i := 0; i < int(node.ChildCount()); i++ {
+		collectIdentifiers(node.Child(i), content, symbols)
+	}
+}
+
+// fileExists looks for a path relative to the comment's file, the repository root or the current
+// directory, then for any file in the repository that ends with it
+func (r *Resolver) fileExists(filePath, path string) bool {
+	for _, candidate := range []string{filepath.Join(filepath.Dir(filePath), path), filepath.Join(r.root, path), path} {
+		if _, err := os.Stat(candidate); err == nil {
+			return true
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.files == nil {
+		r.indexFiles()
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+	if !strings.Contains(path, "/") {
+		return r.names[path]
+	}
+	for file := range r.files {
+		if strings.HasSuffix(file, "/"+path) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexFiles lists every file below the repository root that .gitignore doesn't exclude, by slash
+// separated relative path and by name
+func (r *Resolver) indexFiles() {
+	r.files = make(map[string]bool)
+	r.names = make(map[string]bool)
+	ignore := source.NewIgnoreMatcher()
+	filepath.WalkDir(r.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path != r.root && ignore.Ignored(path) {
+			// Built or vendored files must not make references to them look alive
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rel, err := filepath.Rel(r.root, path); err == nil && rel != "." {
+			r.files["/"+filepath.ToSlash(rel)] = true
+			r.names[d.Name()] = true
+		}
+		return nil
+	})
+}
@@ -0,0 +1,178 @@
+package stash
+
+import (
+	"strings"
+	"walle/internal/comment"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// anchorTokens is the number of code tokens recorded on either side of a comment
+const anchorTokens = 3
+
+// token is a leaf of the syntax tree that is not part of a comment
+type token struct {
+	text  string
+	start uint32
+	end   uint32
+	line  int
+	path  []string
+}
+
+// span is the byte range of a comment already present in the content
+type span struct {
+	text       string
+	start, end uint32
+}
+
+// collectTokens returns the code tokens of a tree in order, and the comments it contains
+func collectTokens(root *sitter.Node, content []byte) ([]token, []span) {
+	var tokens []token
+	var comments []span
+	var path []string
+
+	var walk func(node *sitter.Node)
+	walk = func(node *sitter.Node) {
+		if comment.IsCommentNode(node) {
+			comments = append(comments, span{text: node.Content(content), start: node.StartByte(), end: node.EndByte()})
+			return
+		}
+		if node.ChildCount() == 0 {
+			text := strings.TrimSpace(node.Content(content))
+			if text != "" {
+				tokens = append(tokens, token{
+					text:  text,
+					start: node.StartByte(),
+					end:   node.EndByte(),
+					line:  int(node.StartPoint().Row) + 1,
+					path:  append([]string{}, path...),
+				})
+			}
+			return
+		}
+
+		path = append(path, node.Type())
+		for i := 0; i < int(node.ChildCount()); i++ {
+			walk(node.Child(i))
+		}
+		path = path[:len(path)-1]
+	}
+	walk(root)
+
+	return tokens, comments
+}
+
+// anchorFor records the tokens around a comment and the node types enclosing it
+func anchorFor(root *sitter.Node, tokens []token, c comment.Comment) Anchor {
+	gap := gapIndex(tokens, c.StartByte)
+
+	var anchor Anchor
+	for i := gap - 1; i >= 0 && len(anchor.Before) < anchorTokens; i-- {
+		anchor.Before = append(anchor.Before, tokens[i].text)
+	}
+	for i := gap; i < len(tokens) && len(anchor.After) < anchorTokens; i++ {
+		anchor.After = append(anchor.After, tokens[i].text)
+	}
+
+	node := comment.DescendantForByteRange(root, c.StartByte, c.EndByte)
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		anchor.Path = append([]string{parent.Type()}, anchor.Path...)
+	}
+	return anchor
+}
+
+// gapIndex returns the number of tokens that end at or before offset
+func gapIndex(tokens []token, offset uint32) int {
+	gap := 0
+	for gap < len(tokens) && tokens[gap].end <= offset {
+		gap++
+	}
+	return gap
+}
+
+// locate finds the gap between tokens that matches an anchor best. A gap must match at least
+// half of the anchor tokens. Comments on their own line or before code count the code after them
+// double, trailing comments the code before them. Ties go to the gap with the closest enclosing
+// path and then to the one closest to the original line.
+func locate(tokens []token, entry Entry) (int, bool) {
+	anchor := entry.Anchor
+	if len(anchor.Before) == 0 && len(anchor.After) == 0 {
+		return 0, true
+	}
+	required := max((len(anchor.Before)+len(anchor.After)+1)/2, 1)
+
+	// Only gaps next to a token matching the closest anchor token can score
+	candidates := make(map[int]bool)
+	for i, t := range tokens {
+		if len(anchor.Before) > 0 && t.text == anchor.Before[0] {
+			candidates[i+1] = true
+		}
+		if len(anchor.After) > 0 && t.text == anchor.After[0] {
+			candidates[i] = true
+		}
+	}
+
+	best, bestMatches, bestPath, bestDistance := -1, 0, -1, 0
+	for gap := range candidates {
+		before, after := 0, 0
+		for k := 0; k < len(anchor.Before) && gap-1-k >= 0 && tokens[gap-1-k].text == anchor.Before[k]; k++ {
+			before++
+		}
+		for k := 0; k < len(anchor.After) && gap+k < len(tokens) && tokens[gap+k].text == anchor.After[k]; k++ {
+			after++
+		}
+		if before+after < required {
+			continue
+		}
+
+		matches := 2*after + before
+		if entry.Placement == PlacementTrailing || entry.Placement == PlacementInline {
+			matches = 2*before + after
+		}
+
+		pathMatch := commonPrefix(anchor.Path, gapPath(tokens, gap))
+		distance := abs(gapLine(tokens, gap) - entry.Line)
+		better := matches > bestMatches ||
+			matches == bestMatches && pathMatch > bestPath ||
+			matches == bestMatches && pathMatch == bestPath && (distance < bestDistance || distance == bestDistance && gap < best)
+		if best < 0 || better {
+			best, bestMatches, bestPath, bestDistance = gap, matches, pathMatch, distance
+		}
+	}
+	return best, best >= 0
+}
+
+func gapPath(tokens []token, gap int) []string {
+	if gap < len(tokens) {
+		return tokens[gap].path
+	}
+	if gap > 0 {
+		return tokens[gap-1].path
+	}
+	return nil
+}
+
+func gapLine(tokens []token, gap int) int {
+	if gap < len(tokens) {
+		return tokens[gap].line
+	}
+	if gap > 0 {
+		return tokens[gap-1].line
+	}
+	return 1
+}
+
+func commonPrefix(a, b []string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
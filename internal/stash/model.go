@@ -0,0 +1,63 @@
+package stash
+
+// FileName is the name of the sidecar file stashed comments are kept in
+const FileName = ".walle-comments.json"
+
+// Placement describes where a comment sat relative to the code on its line
+type Placement string
+
+const (
+	// PlacementLine is a comment on lines of its own
+	PlacementLine Placement = "line"
+	// PlacementLeading is a comment followed by code on the same line
+	PlacementLeading Placement = "leading"
+	// PlacementTrailing is a comment at the end of a line of code
+	PlacementTrailing Placement = "trailing"
+	// PlacementInline is a comment between code on both sides
+	PlacementInline Placement = "inline"
+)
+
+// Sidecar is the content of the sidecar file
+type Sidecar struct {
+	Version int    `json:"version"`
+	Files   []File `json:"files"`
+}
+
+// File holds the stashed comments of a single file, Path is relative to the sidecar
+type File struct {
+	Path     string  `json:"path"`
+	Comments []Entry `json:"comments"`
+}
+
+// Entry is a stashed comment and what it was anchored to
+type Entry struct {
+	Text      string    `json:"text"`
+	Line      int       `json:"line"`
+	Placement Placement `json:"placement"`
+	// Indent is the indentation of a comment on its own line
+	Indent string `json:"indent,omitempty"`
+	// BlankBefore and BlankAfter count the blank lines around a comment on its own line
+	BlankBefore int `json:"blank_before,omitempty"`
+	BlankAfter  int `json:"blank_after,omitempty"`
+	// NoNewline is set for a comment that ends the file without a final newline
+	NoNewline bool `json:"no_newline,omitempty"`
+	// Spacing is the whitespace between the comment and the code on its line
+	Spacing string `json:"spacing,omitempty"`
+	Anchor  Anchor `json:"anchor"`
+}
+
+// Anchor locates a comment by the syntax around it rather than by offset, so it survives edits
+type Anchor struct {
+	// Path lists the node types enclosing the comment, from the root down
+	Path []string `json:"path"`
+	// Before and After are the nearest code tokens on either side, closest first
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+}
+
+// Unplaced is a stashed comment that could not be put back
+type Unplaced struct {
+	Path   string
+	Entry  Entry
+	Reason string
+}
@@ -0,0 +1,380 @@
+package stash
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"walle/internal/comment"
+	"walle/internal/source"
+)
+
+// version is the sidecar format written by this release
+const version = 1
+
+// Path returns where the sidecar lives, at the repository root or in the current directory outside a repository
+func Path() (string, error) {
+	if root, err := source.RepoRoot(); err == nil {
+		return filepath.Join(root, FileName), nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cwd, FileName), nil
+}
+
+// Load reads the sidecar, a missing sidecar is empty
+func Load(path string) (Sidecar, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Sidecar{Version: version}, nil
+	}
+	if err != nil {
+		return Sidecar{}, err
+	}
+
+	var sidecar Sidecar
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return Sidecar{}, fmt.Errorf("corrupt sidecar %s: %w", path, err)
+	}
+	if sidecar.Version > version {
+		return Sidecar{}, fmt.Errorf("sidecar %s was written by a newer version of walle", path)
+	}
+	return sidecar, nil
+}
+
+// Save writes the sidecar, it is removed once no stashed comments are left
+func Save(path string, sidecar Sidecar) error {
+	if len(sidecar.Files) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove sidecar: %w", err)
+		}
+		return nil
+	}
+
+	sidecar.Version = version
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write sidecar: %w", err)
+	}
+	return nil
+}
+
+// Add merges the stashed comments of a file into the sidecar
+func (s *Sidecar) Add(file File) {
+	for i := range s.Files {
+		if s.Files[i].Path == file.Path {
+			s.Files[i].Comments = append(s.Files[i].Comments, file.Comments...)
+			return
+		}
+	}
+	s.Files = append(s.Files, file)
+	sort.Slice(s.Files, func(i, j int) bool {
+		return s.Files[i].Path < s.Files[j].Path
+	})
+}
+
+// Replace sets the comments still stashed for a file, dropping the file when none are left
+func (s *Sidecar) Replace(path string, entries []Entry) {
+	for i := range s.Files {
+		if s.Files[i].Path != path {
+			continue
+		}
+		if len(entries) == 0 {
+			s.Files = append(s.Files[:i], s.Files[i+1:]...)
+		} else {
+			s.Files[i].Comments = entries
+		}
+		return
+	}
+}
+
+// Record anchors the comments of a planned edit, dir is the directory of the sidecar
+func Record(dir string, edit comment.FileEdit) (File, error) {
+	absPath, err := filepath.Abs(edit.Path)
+	if err != nil {
+		return File{}, err
+	}
+	relPath, err := filepath.Rel(dir, absPath)
+	if err != nil {
+		return File{}, err
+	}
+
+	tree, err := comment.ParseFile(edit.Path, edit.Original)
+	if err != nil {
+		return File{}, err
+	}
+	defer tree.Close()
+	tokens, _ := collectTokens(tree.RootNode(), edit.Original)
+
+	sorted := make([]comment.Comment, len(edit.Comments))
+	copy(sorted, edit.Comments)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartByte < sorted[j].StartByte
+	})
+
+	file := File{Path: filepath.ToSlash(relPath)}
+	for _, c := range sorted {
+		// Some grammars include the line ending in line comments
+		for c.EndByte > c.StartByte && (edit.Original[c.EndByte-1] == '\n' || edit.Original[c.EndByte-1] == '\r') {
+			c.EndByte--
+		}
+		entry := Entry{
+			Text:      string(edit.Original[c.StartByte:c.EndByte]),
+			Line:      c.Line,
+			Placement: placementOf(edit.Original, c),
+			Anchor:    anchorFor(tree.RootNode(), tokens, c),
+		}
+		switch entry.Placement {
+		case PlacementLine:
+			entry.Indent = indentation(edit.Original, lineStart(edit.Original, c.StartByte))
+			entry.BlankBefore = len(blankRunBefore(edit.Original, lineStart(edit.Original, c.StartByte)))
+			entry.BlankAfter = blankLinesAfter(edit.Original, c.EndByte)
+			entry.NoNewline = int(c.EndByte) == len(edit.Original)
+		case PlacementLeading:
+			entry.Spacing = spacingAfter(edit.Original, c.EndByte)
+		default:
+			entry.Spacing = spacingBefore(edit.Original, c.StartByte)
+		}
+		file.Comments = append(file.Comments, entry)
+	}
+	return file, nil
+}
+
+// insertion is text to add at an offset of the current content
+type insertion struct {
+	offset uint32
+	text   string
+}
+
+// Restore plans putting the stashed comments of a file back, dir is the directory of the sidecar.
+// Comments whose anchor can't be found are returned as unplaced. The edit is verified to only add comments.
+func Restore(dir string, file File) (comment.FileEdit, []Unplaced, error) {
+	path := filepath.Join(dir, filepath.FromSlash(file.Path))
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return comment.FileEdit{}, nil, err
+	}
+
+	tree, err := comment.ParseFile(path, current)
+	if err != nil {
+		return comment.FileEdit{}, nil, err
+	}
+	tokens, existing := collectTokens(tree.RootNode(), current)
+	tree.Close()
+
+	newline := "\n"
+	if bytes.Contains(current, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+
+	var insertions []insertion
+	var unplaced []Unplaced
+	for _, entry := range file.Comments {
+		gap, ok := locate(tokens, entry)
+		if !ok {
+			unplaced = append(unplaced, Unplaced{Path: path, Entry: entry, Reason: "the code around it was not found"})
+			continue
+		}
+		if alreadyPresent(tokens, existing, gap, entry.Text) {
+			continue
+		}
+		insertions = append(insertions, insertAt(current, tokens, gap, entry, newline))
+	}
+
+	sort.SliceStable(insertions, func(i, j int) bool {
+		return insertions[i].offset < insertions[j].offset
+	})
+	var sb bytes.Buffer
+	pos := uint32(0)
+	for _, ins := range insertions {
+		sb.Write(current[pos:ins.offset])
+		sb.WriteString(ins.text)
+		pos = ins.offset
+	}
+	sb.Write(current[pos:])
+	updated := sb.Bytes()
+
+	if err := comment.Verify(path, current, updated); err != nil {
+		return comment.FileEdit{}, nil, err
+	}
+	return comment.FileEdit{Path: path, Original: current, Updated: updated}, unplaced, nil
+}
+
+// insertAt returns the text that puts a comment back into a gap, in the placement it had
+func insertAt(content []byte, tokens []token, gap int, entry Entry, newline string) insertion {
+	placement := entry.Placement
+	if gap == 0 && (placement == PlacementTrailing || placement == PlacementInline) {
+		placement = PlacementLeading
+	}
+	if gap == len(tokens) && (placement == PlacementLeading || placement == PlacementInline) {
+		placement = PlacementTrailing
+	}
+	if len(tokens) == 0 {
+		placement = PlacementLine
+	}
+
+	spacing := entry.Spacing
+	if spacing == "" || placement != entry.Placement {
+		spacing = " "
+	}
+	switch placement {
+	case PlacementLeading:
+		return insertion{offset: tokens[gap].start, text: entry.Text + spacing}
+	case PlacementTrailing:
+		return insertion{offset: lineEnd(content, tokens[gap-1].end), text: spacing + entry.Text}
+	case PlacementInline:
+		return insertion{offset: tokens[gap-1].end, text: spacing + entry.Text}
+	}
+
+	text := strings.ReplaceAll(entry.Text, "\n", newline) + newline
+	if gap == len(tokens) {
+		blank := max(entry.BlankBefore-len(blankRunBefore(content, uint32(len(content)))), 0)
+		text = strings.Repeat(newline, blank) + entry.Indent + text
+		if entry.NoNewline {
+			text = strings.TrimSuffix(text, newline)
+		}
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			text = newline + text
+		}
+		return insertion{offset: uint32(len(content)), text: text}
+	}
+
+	// Cleanup collapsed the blank lines around the comment, put it into the run of blank lines
+	// above the code at the position it had and add back the blank lines that were removed
+	start := lineStart(content, tokens[gap].start)
+	run := blankRunBefore(content, start)
+	skip := min(entry.BlankBefore, len(run))
+	offset := start
+	if skip < len(run) {
+		offset = run[skip]
+	}
+	blank := max(entry.BlankAfter-(len(run)-skip), 0)
+	return insertion{offset: offset, text: indentFor(content, tokens, gap, entry.Indent) + text + strings.Repeat(newline, blank)}
+}
+
+// blankRunBefore returns the starts of the blank lines directly above the line starting at start, top first
+func blankRunBefore(content []byte, start uint32) []uint32 {
+	var run []uint32
+	for start > 0 {
+		previous := lineStart(content, start-1)
+		if len(bytes.TrimSpace(content[previous:start])) > 0 {
+			break
+		}
+		run = append([]uint32{previous}, run...)
+		start = previous
+	}
+	return run
+}
+
+// blankLinesAfter counts the blank lines directly below the line containing pos
+func blankLinesAfter(content []byte, pos uint32) int {
+	i := bytes.IndexByte(content[pos:], '\n')
+	if i < 0 {
+		return 0
+	}
+	count := 0
+	for start := pos + uint32(i) + 1; start < uint32(len(content)); {
+		end := uint32(len(content))
+		if j := bytes.IndexByte(content[start:], '\n'); j >= 0 {
+			end = start + uint32(j) + 1
+		}
+		if len(bytes.TrimSpace(content[start:end])) > 0 {
+			break
+		}
+		count++
+		start = end
+	}
+	return count
+}
+
+// indentFor picks the indentation of the code after the gap, or of the code before it when that
+// is how the comment was indented, such as a comment at the end of a block
+func indentFor(content []byte, tokens []token, gap int, original string) string {
+	indent := indentation(content, lineStart(content, tokens[gap].start))
+	if indent != original && gap > 0 {
+		if previous := indentation(content, lineStart(content, tokens[gap-1].start)); previous == original {
+			return previous
+		}
+	}
+	return indent
+}
+
+// alreadyPresent reports whether the gap already holds the comment, so unstashing twice is harmless
+func alreadyPresent(tokens []token, existing []span, gap int, text string) bool {
+	from, to := uint32(0), ^uint32(0)
+	if gap > 0 {
+		from = tokens[gap-1].end
+	}
+	if gap < len(tokens) {
+		to = tokens[gap].start
+	}
+	for _, s := range existing {
+		if s.start >= from && s.end <= to && strings.TrimRight(s.text, "\r") == text {
+			return true
+		}
+	}
+	return false
+}
+
+// placementOf classifies where a comment sits relative to the code on its lines
+func placementOf(content []byte, c comment.Comment) Placement {
+	before := strings.TrimSpace(string(content[lineStart(content, c.StartByte):c.StartByte]))
+	after := strings.TrimSpace(string(content[c.EndByte:lineEnd(content, c.EndByte)]))
+	switch {
+	case before == "" && after == "":
+		return PlacementLine
+	case before == "":
+		return PlacementLeading
+	case after == "":
+		return PlacementTrailing
+	}
+	return PlacementInline
+}
+
+func lineStart(content []byte, pos uint32) uint32 {
+	return uint32(bytes.LastIndexByte(content[:pos], '\n') + 1)
+}
+
+// lineEnd returns the offset of the line ending after pos, before any \r
+func lineEnd(content []byte, pos uint32) uint32 {
+	end := uint32(len(content))
+	if i := bytes.IndexByte(content[pos:], '\n'); i >= 0 {
+		end = pos + uint32(i)
+	}
+	if end > pos && content[end-1] == '\r' {
+		end--
+	}
+	return end
+}
+
+func spacingBefore(content []byte, pos uint32) string {
+	start := pos
+	for start > 0 && (content[start-1] == ' ' || content[start-1] == '\t') {
+		start--
+	}
+	return string(content[start:pos])
+}
+
+func spacingAfter(content []byte, pos uint32) string {
+	end := pos
+	for end < uint32(len(content)) && (content[end] == ' ' || content[end] == '\t') {
+		end++
+	}
+	return string(content[pos:end])
+}
+
+func indentation(content []byte, start uint32) string {
+	end := start
+	for end < uint32(len(content)) && (content[end] == ' ' || content[end] == '\t') {
+		end++
+	}
+	return string(content[start:end])
+}
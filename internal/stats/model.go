@@ -0,0 +1,47 @@
+package stats
+
+type GroupBy string
+
+const (
+	GroupByDir  GroupBy = "dir"
+	GroupByLang GroupBy = "lang"
+	GroupByFile GroupBy = "file"
+)
+
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJSON  Format = "json"
+	FormatCSV   Format = "csv"
+)
+
+// FileStats holds the line and comment counts for a single file
+type FileStats struct {
+	Path         string
+	Language     string
+	Dir          string
+	CodeLines    int
+	CommentLines int
+	BlankLines   int
+	Comments     int
+	Kinds        map[string]int
+}
+
+// Row is one aggregated line of a stats report
+type Row struct {
+	Name         string         `json:"name"`
+	Files        int            `json:"files"`
+	CodeLines    int            `json:"code_lines"`
+	CommentLines int            `json:"comment_lines"`
+	BlankLines   int            `json:"blank_lines"`
+	Comments     int            `json:"comments"`
+	Density      float64        `json:"density"`
+	Kinds        map[string]int `json:"kinds"`
+}
+
+type Report struct {
+	GroupBy GroupBy `json:"group_by"`
+	Rows    []Row   `json:"rows"`
+	Total   Row     `json:"total"`
+}
@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"walle/internal/comment"
+)
+
+// Write renders the report in the given format
+func Write(w io.Writer, report Report, format Format) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, report)
+	case FormatCSV:
+		return writeCSV(w, report)
+	default:
+		return writeTable(w, report)
+	}
+}
+
+func header(report Report) []string {
+	columns := []string{string(report.GroupBy), "files", "code", "comment lines", "blank", "comments", "density"}
+	for _, kind := range comment.AllKinds {
+		columns = append(columns, kind.String())
+	}
+	return columns
+}
+
+func values(row Row, percent bool) []string {
+	d := strconv.FormatFloat(row.Density, 'f', 4, 64)
+	if percent {
+		d = fmt.Sprintf("%.1f%%", row.Density*100)
+	}
+	columns := []string{
+		row.Name,
+		strconv.Itoa(row.Files),
+		strconv.Itoa(row.CodeLines),
+		strconv.Itoa(row.CommentLines),
+		strconv.Itoa(row.BlankLines),
+		strconv.Itoa(row.Comments),
+		d,
+	}
+	for _, kind := range comment.AllKinds {
+		columns = append(columns, strconv.Itoa(row.Kinds[kind.String()]))
+	}
+	return columns
+}
+
+func writeTable(w io.Writer, report Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	writeLine := func(columns []string) {
+		for _, column := range columns {
+			fmt.Fprintf(tw, "%s\t", column)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	writeLine(header(report))
+	for _, row := range report.Rows {
+		writeLine(values(row, true))
+	}
+	writeLine(values(report.Total, true))
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, report Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func writeCSV(w io.Writer, report Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header(report)); err != nil {
+		return err
+	}
+	for _, row := range report.Rows {
+		if err := cw.Write(values(row, false)); err != nil {
+			return err
+		}
+	}
+	if err := cw.Write(values(report.Total, false)); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
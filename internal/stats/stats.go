@@ -0,0 +1,173 @@
+package stats
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"walle/internal/comment"
+	"walle/internal/languages"
+	"walle/internal/source"
+)
+
+// ParseGroupBy validates a --group-by value
+func ParseGroupBy(value string) (GroupBy, error) {
+	switch GroupBy(value) {
+	case GroupByDir, GroupByLang, GroupByFile:
+		return GroupBy(value), nil
+	}
+	return "", fmt.Errorf("invalid group-by %q (expected dir, lang or file)", value)
+}
+
+// ParseFormat validates a --format value
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case FormatTable, FormatJSON, FormatCSV:
+		return Format(value), nil
+	}
+	return "", fmt.Errorf("invalid format %q (expected table, json or csv)", value)
+}
+
+// CountFile classifies every line of a file as code, comment or blank.
+// comments must contain all comments in the file, for diffed files only the added lines are counted.
+func CountFile(file source.File, comments []comment.Comment) FileStats {
+	stats := FileStats{
+		Path:     file.Path,
+		Language: languages.GetLanguageNameForExtension(strings.ToLower(filepath.Ext(file.Path))),
+		Dir:      topLevelDir(file.Path),
+		Kinds:    make(map[string]int),
+	}
+
+	inComment := make([]bool, len(file.Content))
+	for _, c := range comments {
+		for i := c.StartByte; i < c.EndByte && int(i) < len(inComment); i++ {
+			inComment[i] = true
+		}
+		if inScope(file, c.Line) {
+			stats.Comments++
+			stats.Kinds[c.Kind.String()]++
+		}
+	}
+
+	line := 1
+	hasCode, hasComment := false, false
+	flush := func() {
+		if inScope(file, line) {
+			switch {
+			case hasCode:
+				stats.CodeLines++
+			case hasComment:
+				stats.CommentLines++
+			default:
+				stats.BlankLines++
+			}
+		}
+		line++
+		hasCode, hasComment = false, false
+	}
+
+	for i, b := range file.Content {
+		if b == '\n' {
+			flush()
+			continue
+		}
+		if inComment[i] {
+			hasComment = true
+		} else if b != ' ' && b != '\t' && b != '\r' {
+			hasCode = true
+		}
+	}
+	if len(file.Content) > 0 && file.Content[len(file.Content)-1] != '\n' {
+		flush()
+	}
+
+	return stats
+}
+
+// Aggregate groups file stats into a report
+func Aggregate(files []FileStats, groupBy GroupBy) Report {
+	rows := make(map[string]*Row)
+	total := newRow("total")
+
+	for _, f := range files {
+		var key string
+		switch groupBy {
+		case GroupByDir:
+			key = f.Dir
+		case GroupByLang:
+			key = f.Language
+		default:
+			key = f.Path
+		}
+
+		row, ok := rows[key]
+		if !ok {
+			row = newRow(key)
+			rows[key] = row
+		}
+		row.add(f)
+		total.add(f)
+	}
+
+	report := Report{GroupBy: groupBy, Rows: []Row{}}
+	for _, row := range rows {
+		row.Density = density(row)
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		return report.Rows[i].Name < report.Rows[j].Name
+	})
+
+	total.Density = density(total)
+	report.Total = *total
+	return report
+}
+
+func newRow(name string) *Row {
+	row := &Row{Name: name, Kinds: make(map[string]int)}
+	for _, kind := range comment.AllKinds {
+		row.Kinds[kind.String()] = 0
+	}
+	return row
+}
+
+func (r *Row) add(f FileStats) {
+	r.Files++
+	r.CodeLines += f.CodeLines
+	r.CommentLines += f.CommentLines
+	r.BlankLines += f.BlankLines
+	r.Comments += f.Comments
+	for kind, count := range f.Kinds {
+		r.Kinds[kind] += count
+	}
+}
+
+// density is the share of non-blank lines that are comments
+func density(r *Row) float64 {
+	lines := r.CodeLines + r.CommentLines
+	if lines == 0 {
+		return 0
+	}
+	return float64(r.CommentLines) / float64(lines)
+}
+
+func inScope(file source.File, line int) bool {
+	if file.Status == source.StatusAdded || file.Status == source.StatusUntracked {
+		return true
+	}
+	for _, r := range file.DiffRanges {
+		if line >= r.Start && line <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// topLevelDir returns the first directory of path below the repository root, "." for files in the root
+func topLevelDir(path string) string {
+	parts := strings.Split(source.RepoPath(path), "/")
+	if len(parts) < 2 {
+		return "."
+	}
+	return parts[0]
+}
@@ -0,0 +1,68 @@
+package todo
+
+import (
+	"os"
+	"strings"
+	"time"
+	"walle/internal/source"
+)
+
+// Blame sets the author, date and age of every todo from git blame. Lines are matched to HEAD by
+// their text, so edits above a TODO don't lose its history. Lines not in HEAD are new, with age 0.
+func Blame(todos []Todo, blamer *source.Blamer, now time.Time) error {
+	type blamed struct {
+		lines   []source.BlameLine
+		current []string
+	}
+	files := make(map[string]*blamed)
+
+	for i := range todos {
+		t := &todos[i]
+		file, ok := files[t.Path]
+		if !ok {
+			lines, _, err := blamer.Blame(t.Path)
+			if err != nil {
+				return err
+			}
+			content, err := os.ReadFile(t.Path)
+			if err != nil {
+				return err
+			}
+			file = &blamed{lines: lines, current: strings.Split(string(content), "\n")}
+			files[t.Path] = file
+		}
+
+		if line, ok := matchLine(file.lines, file.current, t.Line); ok {
+			date := line.Date
+			t.Author = line.Author
+			t.Date = &date
+			t.AgeDays = int(now.Sub(date).Hours() / 24)
+		}
+		if t.Owner == "" {
+			t.Owner = t.Author
+		}
+	}
+	return nil
+}
+
+// matchLine finds the HEAD line with the same text as line of the current content, nearest to it
+func matchLine(lines []source.BlameLine, current []string, line int) (source.BlameLine, bool) {
+	if line < 1 || line > len(current) {
+		return source.BlameLine{}, false
+	}
+	text := strings.TrimRight(current[line-1], "\r")
+	best, bestDistance := -1, 0
+	for i, l := range lines {
+		if strings.TrimRight(l.Text, "\r") != text {
+			continue
+		}
+		distance := max(i+1-line, line-i-1)
+		if best < 0 || distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	if best < 0 {
+		return source.BlameLine{}, false
+	}
+	return lines[best], true
+}
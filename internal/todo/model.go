@@ -0,0 +1,52 @@
+package todo
+
+import (
+	"regexp"
+	"time"
+)
+
+// PolicyFile is the policy read from the repository root when no other file is given
+const PolicyFile = ".walle-policy.json"
+
+// Markers are the words that make a comment a TODO
+var Markers = []string{"TODO", "FIXME", "HACK", "XXX"}
+
+// Policy sets the rules TODO comments must follow, keyed by marker
+type Policy struct {
+	Markers map[string]Rule `json:"markers"`
+}
+
+// Rule is the policy for a single marker
+type Rule struct {
+	// Require is a regular expression the text from the marker on must match, such as a ticket reference
+	Require string `json:"require,omitempty"`
+	// Forbidden markers are never allowed
+	Forbidden bool `json:"forbidden,omitempty"`
+
+	require *regexp.Regexp
+}
+
+// Todo is a comment with a marker
+type Todo struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Marker string `json:"marker"`
+	// Owner is the name in TODO(name), or the author of the line when there is none
+	Owner  string `json:"owner,omitempty"`
+	Ticket string `json:"ticket,omitempty"`
+	Text   string `json:"text"`
+	// Author and Date are from git blame, empty for lines that are not committed
+	Author    string     `json:"author,omitempty"`
+	Date      *time.Time `json:"date,omitempty"`
+	AgeDays   int        `json:"age_days"`
+	Compliant bool       `json:"compliant"`
+	Violation string     `json:"violation,omitempty"`
+}
+
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatJSON  Format = "json"
+	FormatCSV   Format = "csv"
+)
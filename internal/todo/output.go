@@ -0,0 +1,87 @@
+package todo
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Write renders the todos in the given format
+func Write(w io.Writer, todos []Todo, format Format) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, todos)
+	case FormatCSV:
+		return writeCSV(w, todos)
+	default:
+		return writeTable(w, todos)
+	}
+}
+
+func writeTable(w io.Writer, todos []Todo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "location\tmarker\towner\tticket\tage\tpolicy\ttext")
+	for _, t := range todos {
+		age := "new"
+		if t.Date != nil {
+			age = strconv.Itoa(t.AgeDays) + "d"
+		}
+		policy := "ok"
+		if !t.Compliant {
+			policy = t.Violation
+		}
+		fmt.Fprintf(tw, "%s:%d\t%s\t%s\t%s\t%s\t%s\t%s\n", t.Path, t.Line, t.Marker, dash(t.Owner), dash(t.Ticket), age, policy, truncate(t.Text, 60))
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, todos []Todo) error {
+	if todos == nil {
+		todos = []Todo{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(todos)
+}
+
+func writeCSV(w io.Writer, todos []Todo) error {
+	cw := csv.NewWriter(w)
+	header := []string{"path", "line", "marker", "owner", "ticket", "author", "date", "age_days", "compliant", "violation", "text"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, t := range todos {
+		date := ""
+		if t.Date != nil {
+			date = t.Date.Format(time.RFC3339)
+		}
+		row := []string{
+			t.Path, strconv.Itoa(t.Line), t.Marker, t.Owner, t.Ticket, t.Author, date,
+			strconv.Itoa(t.AgeDays), strconv.FormatBool(t.Compliant), t.Violation, t.Text,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func dash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,138 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"walle/internal/comment"
+)
+
+var (
+	markerPattern = regexp.MustCompile(`\b(TODO|FIXME|HACK|XXX)\b(?:\(([^)]*)\))?`)
+	ticketPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-[0-9]+\b|#[0-9]+\b|https?://\S+`)
+)
+
+// Parse finds the marker of a comment, ok is false for comments without one
+func Parse(c comment.Comment) (Todo, bool) {
+	if c.Kind == comment.KindDirective {
+		return Todo{}, false
+	}
+	text := comment.StripMarkers(c.Text)
+	match := markerPattern.FindStringSubmatchIndex(text)
+	if match == nil {
+		return Todo{}, false
+	}
+
+	t := Todo{
+		Path:   c.FilePath,
+		Line:   c.Line + strings.Count(text[:match[0]], "\n"),
+		Marker: text[match[2]:match[3]],
+		Text:   comment.NormalizeText(text[match[0]:]),
+	}
+	if match[4] >= 0 {
+		// TODO(alice), TODO(ABC-123) or TODO(alice, ABC-123)
+		for _, part := range strings.Split(text[match[4]:match[5]], ",") {
+			part = strings.TrimSpace(part)
+			if ticketPattern.MatchString(part) {
+				t.Ticket = ticketPattern.FindString(part)
+			} else if part != "" && t.Owner == "" {
+				t.Owner = part
+			}
+		}
+	}
+	if t.Ticket == "" {
+		t.Ticket = ticketPattern.FindString(text[match[1]:])
+	}
+	return t, true
+}
+
+// Check applies the policy to a todo, setting Compliant and Violation
+func (p *Policy) Check(t *Todo) {
+	t.Compliant = true
+	t.Violation = ""
+	rule, ok := p.Markers[t.Marker]
+	if !ok {
+		return
+	}
+	switch {
+	case rule.Forbidden:
+		t.Compliant = false
+		t.Violation = t.Marker + " is not allowed"
+	case rule.require != nil && !rule.require.MatchString(t.Text):
+		t.Compliant = false
+		t.Violation = fmt.Sprintf("%s must match %s", t.Marker, rule.Require)
+	}
+}
+
+// KeepCompliant returns a filter for fix that leaves compliant TODOs in place, every other comment,
+// non-compliant TODOs included, is removed as usual
+func (p *Policy) KeepCompliant() func(*comment.Comment) bool {
+	return func(c *comment.Comment) bool {
+		t, ok := Parse(*c)
+		if !ok {
+			return true
+		}
+		p.Check(&t)
+		return !t.Compliant
+	}
+}
+
+// LoadPolicy reads a policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+	for marker, rule := range p.Markers {
+		if !isMarker(marker) {
+			return nil, fmt.Errorf("invalid policy %s: unknown marker %q (expected TODO, FIXME, HACK or XXX)", path, marker)
+		}
+		if rule.Require != "" {
+			rule.require, err = regexp.Compile(rule.Require)
+			if err != nil {
+				return nil, fmt.Errorf("invalid policy %s: %s: %w", path, marker, err)
+			}
+		}
+		p.Markers[marker] = rule
+	}
+	return &p, nil
+}
+
+// FindPolicy loads path, or PolicyFile from root when path is empty. Without a policy file it returns
+// nil and no error.
+func FindPolicy(path, root string) (*Policy, error) {
+	if path != "" {
+		return LoadPolicy(path)
+	}
+	p, err := LoadPolicy(filepath.Join(root, PolicyFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return p, err
+}
+
+func isMarker(name string) bool {
+	for _, marker := range Markers {
+		if marker == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFormat converts a --format value into a Format
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case FormatTable, FormatJSON, FormatCSV:
+		return Format(name), nil
+	}
+	return "", fmt.Errorf("unknown format %q (expected table, json or csv)", name)
+}
@@ -0,0 +1,75 @@
+package trojan
+
+// Check names the unsafe unicode findings
+const Check = "unsafe-unicode"
+
+// Class groups the characters that can make code read differently from how it runs
+type Class int
+
+const (
+	// Bidi controls reorder the text around them, see CVE-2021-42574
+	Bidi Class = iota
+	// Invisible characters such as zero-width joiners hide text or split words
+	Invisible
+	// Confusable letters from another script look like Latin ones in a Latin word
+	Confusable
+)
+
+func (c Class) String() string {
+	switch c {
+	case Bidi:
+		return "bidi control"
+	case Invisible:
+		return "invisible character"
+	case Confusable:
+		return "confusable character"
+	}
+	return "unknown"
+}
+
+// Char is an unsafe character in a comment
+type Char struct {
+	Class Class
+	Rune  rune
+	// Offset is the byte offset in the text that was searched
+	Offset int
+	// Latin is the letter a confusable character looks like
+	Latin rune
+}
+
+var bidi = map[rune]string{
+	'\u061C': "ARABIC LETTER MARK",
+	'\u200E': "LEFT-TO-RIGHT MARK",
+	'\u200F': "RIGHT-TO-LEFT MARK",
+	'\u202A': "LEFT-TO-RIGHT EMBEDDING",
+	'\u202B': "RIGHT-TO-LEFT EMBEDDING",
+	'\u202C': "POP DIRECTIONAL FORMATTING",
+	'\u202D': "LEFT-TO-RIGHT OVERRIDE",
+	'\u202E': "RIGHT-TO-LEFT OVERRIDE",
+	'\u2066': "LEFT-TO-RIGHT ISOLATE",
+	'\u2067': "RIGHT-TO-LEFT ISOLATE",
+	'\u2068': "FIRST STRONG ISOLATE",
+	'\u2069': "POP DIRECTIONAL ISOLATE",
+}
+
+var invisible = map[rune]string{
+	'\u00AD': "SOFT HYPHEN",
+	'\u180E': "MONGOLIAN VOWEL SEPARATOR",
+	'\u200B': "ZERO WIDTH SPACE",
+	'\u200C': "ZERO WIDTH NON-JOINER",
+	'\u200D': "ZERO WIDTH JOINER",
+	'\u2028': "LINE SEPARATOR",
+	'\u2029': "PARAGRAPH SEPARATOR",
+	'\u2060': "WORD JOINER",
+	'\u2062': "INVISIBLE TIMES",
+	'\u2063': "INVISIBLE SEPARATOR",
+	'\u2064': "INVISIBLE PLUS",
+	'\uFEFF': "ZERO WIDTH NO-BREAK SPACE",
+}
+
+// confusables maps Cyrillic and Greek letters to the Latin letters they are drawn like
+var confusables = map[rune]rune{
+	'\u0430': 'a', '\u0435': 'e', '\u043E': 'o', '\u0440': 'p', '\u0441': 'c', '\u0443': 'y', '\u0445': 'x', '\u0456': 'i', '\u0458': 'j', '\u0455': 's', '\u0501': 'd', '\u04BB': 'h',
+	'\u0410': 'A', '\u0412': 'B', '\u0415': 'E', '\u041A': 'K', '\u041C': 'M', '\u041D': 'H', '\u041E': 'O', '\u0420': 'P', '\u0421': 'C', '\u0422': 'T', '\u0425': 'X', '\u0406': 'I', '\u0408': 'J', '\u0405': 'S',
+	'\u03BF': 'o', '\u03BD': 'v', '\u0391': 'A', '\u0392': 'B', '\u0395': 'E', '\u0396': 'Z', '\u0397': 'H', '\u0399': 'I', '\u039A': 'K', '\u039C': 'M', '\u039D': 'N', '\u039F': 'O', '\u03A1': 'P', '\u03A4': 'T', '\u03A5': 'Y', '\u03A7': 'X',
+}
@@ -0,0 +1,108 @@
+package trojan
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"walle/internal/comment"
+)
+
+// Find returns the bidi controls, invisible characters and confusable letters in text.
+// Letters of another script only count inside a word that also has Latin letters, so comments
+// written in Russian or Greek are not reported.
+func Find(text string) []Char {
+	var chars []Char
+	wordStart := -1
+	for i, r := range text {
+		if _, ok := bidi[r]; ok {
+			chars = append(chars, Char{Class: Bidi, Rune: r, Offset: i})
+		} else if _, ok := invisible[r]; ok {
+			chars = append(chars, Char{Class: Invisible, Rune: r, Offset: i})
+		}
+
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			if wordStart < 0 {
+				wordStart = i
+			}
+			continue
+		}
+		if wordStart >= 0 {
+			chars = append(chars, confusablesIn(text[wordStart:i], wordStart)...)
+			wordStart = -1
+		}
+	}
+	if wordStart >= 0 {
+		chars = append(chars, confusablesIn(text[wordStart:], wordStart)...)
+	}
+	return chars
+}
+
+// confusablesIn returns the confusable letters of a word that mixes them with Latin letters
+func confusablesIn(word string, offset int) []Char {
+	latin := false
+	for _, r := range word {
+		if unicode.Is(unicode.Latin, r) {
+			latin = true
+			break
+		}
+	}
+	if !latin {
+		return nil
+	}
+
+	var chars []Char
+	for i, r := range word {
+		if l, ok := confusables[r]; ok {
+			chars = append(chars, Char{Class: Confusable, Rune: r, Offset: offset + i, Latin: l})
+		}
+	}
+	return chars
+}
+
+// Strip removes the bidi controls and invisible characters from text and replaces confusable letters
+// by the Latin letters they look like
+func Strip(text string) string {
+	chars := Find(text)
+	if len(chars) == 0 {
+		return text
+	}
+
+	var sb strings.Builder
+	pos := 0
+	for _, c := range chars {
+		sb.WriteString(text[pos:c.Offset])
+		if c.Class == Confusable {
+			sb.WriteRune(c.Latin)
+		}
+		pos = c.Offset + utf8.RuneLen(c.Rune)
+	}
+	sb.WriteString(text[pos:])
+	return sb.String()
+}
+
+// Annotate adds a finding for each kind of unsafe character on each line of a comment. Bidi controls
+// and invisible characters are high severity, confusable letters medium.
+func Annotate(c *comment.Comment) {
+	seen := make(map[string]bool)
+	for _, ch := range Find(c.Text) {
+		finding := comment.Finding{Check: Check, Severity: comment.SeverityHigh}
+		switch ch.Class {
+		case Bidi:
+			finding.Message = fmt.Sprintf("%s U+%04X %s", ch.Class, ch.Rune, bidi[ch.Rune])
+		case Invisible:
+			finding.Message = fmt.Sprintf("%s U+%04X %s", ch.Class, ch.Rune, invisible[ch.Rune])
+		case Confusable:
+			finding.Severity = comment.SeverityMedium
+			finding.Message = fmt.Sprintf("%s U+%04X looks like %q", ch.Class, ch.Rune, ch.Latin)
+		}
+		if line := c.Line + strings.Count(c.Text[:ch.Offset], "\n"); line != c.Line {
+			finding.Message += fmt.Sprintf(" on line %d", line)
+		}
+		if seen[finding.Message] {
+			continue
+		}
+		seen[finding.Message] = true
+		c.Findings = append(c.Findings, finding)
+	}
+}
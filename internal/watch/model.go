@@ -0,0 +1,42 @@
+package watch
+
+import (
+	"time"
+	"walle/internal/comment"
+)
+
+// DefaultDebounce is how long the watcher waits for events to settle before rescanning
+const DefaultDebounce = 300 * time.Millisecond
+
+// Options configures a watcher
+type Options struct {
+	// Fix removes new comments from files as they are saved
+	Fix             bool
+	Debounce        time.Duration
+	IgnoreGitIgnore bool
+}
+
+// Update is sent after every rescan
+type Update struct {
+	Time time.Time
+	// Comments holds the new comments of every file that has any, keyed by path
+	Comments map[string][]comment.Comment
+	// Events describe what happened in this rescan, such as comments that were removed
+	Events []Event
+}
+
+// Event is a line for the activity log of the watch view
+type Event struct {
+	Time    time.Time
+	Message string
+	Warning bool
+}
+
+// Total returns the number of new comments across all files
+func (u Update) Total() int {
+	total := 0
+	for _, comments := range u.Comments {
+		total += len(comments)
+	}
+	return total
+}
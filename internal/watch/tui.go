@@ -0,0 +1,201 @@
+package watch
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"walle/internal/comment"
+	"walle/internal/render"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxEvents is the number of activity lines kept in the view
+const maxEvents = 8
+
+type updateMsg Update
+
+// doneMsg is sent when the watcher stops, err is nil when it stopped cleanly
+type doneMsg struct {
+	err error
+}
+
+type model struct {
+	updates <-chan Update
+	done    <-chan error
+	opts    Options
+
+	latest  Update
+	started bool
+	events  []Event
+	width   int
+	err     error
+
+	title   lipgloss.Style
+	faint   lipgloss.Style
+	header  lipgloss.Style
+	warning lipgloss.Style
+	success lipgloss.Style
+	kinds   map[comment.Kind]lipgloss.Style
+}
+
+// RunTUI shows a live summary of the new comments until q or ctrl+c is pressed
+func RunTUI(w *Watcher, out *render.Renderer) error {
+	updates := make(chan Update)
+	done := make(chan error, 1)
+	stop := make(chan struct{})
+	go func() {
+		done <- w.Run(updates, stop)
+	}()
+	defer close(stop)
+
+	m := model{
+		updates: updates,
+		done:    done,
+		opts:    w.opts,
+		title:   out.NewStyle().Bold(true),
+		faint:   out.NewStyle().Faint(true),
+		header:  out.NewStyle().Bold(true).Foreground(lipgloss.Color("4")),
+		warning: out.NewStyle().Foreground(lipgloss.Color("3")),
+		success: out.NewStyle().Foreground(lipgloss.Color("2")),
+		kinds: map[comment.Kind]lipgloss.Style{
+			comment.KindLine:      out.NewStyle().Foreground(lipgloss.Color("6")),
+			comment.KindBlock:     out.NewStyle().Foreground(lipgloss.Color("5")),
+			comment.KindDoc:       out.NewStyle().Foreground(lipgloss.Color("2")),
+			comment.KindDirective: out.NewStyle().Foreground(lipgloss.Color("3")),
+		},
+	}
+
+	final, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return err
+	}
+	return final.(model).err
+}
+
+// RunPlain prints a summary after every update until interrupt fires, for output that is not a terminal
+func RunPlain(w *Watcher, out *render.Renderer, interrupt <-chan os.Signal) error {
+	updates := make(chan Update)
+	done := make(chan error, 1)
+	stop := make(chan struct{})
+	go func() {
+		done <- w.Run(updates, stop)
+	}()
+	defer close(stop)
+
+	for {
+		select {
+		case <-interrupt:
+			return nil
+		case err := <-done:
+			return err
+		case update := <-updates:
+			for _, event := range update.Events {
+				if event.Warning {
+					out.Warning("%s", event.Message)
+				} else {
+					out.Success("%s", event.Message)
+				}
+			}
+			out.Summary("[%s] %d new comments in %d files", update.Time.Format("15:04:05"), update.Total(), len(update.Comments))
+		}
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return m.wait()
+}
+
+// wait delivers the next update or the end of the watcher
+func (m model) wait() tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case update := <-m.updates:
+			return updateMsg(update)
+		case err := <-m.done:
+			return doneMsg{err: err}
+		}
+	}
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case updateMsg:
+		m.latest = Update(msg)
+		m.started = true
+		m.events = append(m.events, msg.Events...)
+		if len(m.events) > maxEvents {
+			m.events = m.events[len(m.events)-maxEvents:]
+		}
+		return m, m.wait()
+	case doneMsg:
+		m.err = msg.err
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m model) View() string {
+	var sb strings.Builder
+
+	mode := "watching"
+	if m.opts.Fix {
+		mode = "watching, removing new comments on save"
+	}
+	sb.WriteString(m.title.Render("walle watch") + m.faint.Render("  "+mode) + "\n\n")
+
+	if !m.started {
+		sb.WriteString("Scanning changes...\n")
+		return sb.String()
+	}
+
+	sb.WriteString(m.title.Render(fmt.Sprintf("%d new comments in %d files", m.latest.Total(), len(m.latest.Comments))))
+	sb.WriteString(m.faint.Render("  updated " + m.latest.Time.Format("15:04:05")))
+	sb.WriteString("\n\n")
+
+	paths := make([]string, 0, len(m.latest.Comments))
+	for path := range m.latest.Comments {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		comments := m.latest.Comments[path]
+		sb.WriteString(m.header.Render(path) + " " + m.faint.Render(fmt.Sprintf("(%d comments)", len(comments))) + "\n")
+		for _, c := range comments {
+			text := strings.SplitN(strings.TrimSpace(c.Text), "\n", 2)[0]
+			sb.WriteString(fmt.Sprintf("  %s %s\n", m.faint.Render(fmt.Sprintf("%5d", c.Line)), m.kinds[c.Kind].Render(m.truncate(text, 10))))
+		}
+	}
+
+	if len(m.events) > 0 {
+		sb.WriteString("\n" + m.title.Render("Activity") + "\n")
+		for _, event := range m.events {
+			style := m.success
+			if event.Warning {
+				style = m.warning
+			}
+			sb.WriteString(m.faint.Render(event.Time.Format("15:04:05")) + " " + style.Render(m.truncate(event.Message, 12)) + "\n")
+		}
+	}
+
+	sb.WriteString("\n" + m.faint.Render("q quit") + "\n")
+	return sb.String()
+}
+
+// truncate shortens text to fit the terminal after a prefix of the given width
+func (m model) truncate(text string, prefix int) string {
+	limit := m.width - prefix
+	if m.width == 0 || len([]rune(text)) <= limit || limit < 4 {
+		return text
+	}
+	return string([]rune(text)[:limit-1]) + "…"
+}
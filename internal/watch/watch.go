@@ -0,0 +1,257 @@
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+	"walle/internal/comment"
+	"walle/internal/journal"
+	"walle/internal/source"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher rescans files when they change and keeps the new comments of the worktree up to date
+type Watcher struct {
+	opts     Options
+	fs       *fsnotify.Watcher
+	ignore   *source.IgnoreMatcher
+	comments map[string][]comment.Comment
+}
+
+// New watches the current directory and every directory below it that is not ignored
+func New(opts Options) (*Watcher, error) {
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start file watcher: %w", err)
+	}
+	w := &Watcher{
+		opts:     opts,
+		fs:       fsWatcher,
+		ignore:   source.NewIgnoreMatcher(),
+		comments: make(map[string][]comment.Comment),
+	}
+	if _, err := w.addTree("."); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	return w.fs.Close()
+}
+
+// Run sends the comments of the current changes, then an update after every batch of file events
+// until stop is closed. Comments are only removed by --fix when a file is saved, not at startup.
+func (w *Watcher) Run(updates chan<- Update, stop <-chan struct{}) error {
+	if err := w.initialScan(); err != nil {
+		return err
+	}
+	if !send(updates, stop, w.update(nil)) {
+		return nil
+	}
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(0)
+	<-timer.C
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				// New directories must be watched too, fsnotify is not recursive
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					files, err := w.addTree(event.Name)
+					if err != nil {
+						if !send(updates, stop, w.update([]Event{warning("⚠️  Error watching %s: %v", event.Name, err)})) {
+							return nil
+						}
+					}
+					// Files moved or copied in with the directory came before it was watched
+					for _, file := range files {
+						pending[filepath.Clean(file)] = true
+					}
+					if len(files) > 0 {
+						timer.Reset(w.opts.Debounce)
+					}
+					continue
+				}
+			}
+			if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			pending[filepath.Clean(event.Name)] = true
+			timer.Reset(w.opts.Debounce)
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return nil
+			}
+			if !send(updates, stop, w.update([]Event{warning("⚠️  Watch error: %v", err)})) {
+				return nil
+			}
+		case <-timer.C:
+			var events []Event
+			for path := range pending {
+				events = append(events, w.rescan(path)...)
+			}
+			pending = make(map[string]bool)
+			if !send(updates, stop, w.update(events)) {
+				return nil
+			}
+		}
+	}
+}
+
+// addTree watches dir and the directories below it, skipping ignored ones.
+// It returns the files it found in them that walle can scan.
+func (w *Watcher) addTree(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// A directory removed while walking is not an error
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			if _, err := comment.GetScanner(path); err == nil && !w.ignored(path) {
+				files = append(files, path)
+			}
+			return nil
+		}
+		if path != "." && w.ignored(path) {
+			return filepath.SkipDir
+		}
+		if err := w.fs.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func (w *Watcher) ignored(path string) bool {
+	if filepath.Base(path) == ".git" {
+		return true
+	}
+	return !w.opts.IgnoreGitIgnore && w.ignore.Ignored(path)
+}
+
+// initialScan finds the new comments in the current changes, untracked files included
+func (w *Watcher) initialScan() error {
+	files, err := (&source.GitScanner{}).GetFiles(source.ScanOptions{
+		Type:             source.ScanDiff,
+		IncludeUntracked: true,
+		IgnoreGitIgnore:  w.opts.IgnoreGitIgnore,
+	})
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		scanner, err := comment.GetScanner(file.Path)
+		if err != nil {
+			continue
+		}
+		comments, err := scanner.Scan(file)
+		if err != nil {
+			continue
+		}
+		comments = comment.WithoutKept(comments)
+		if len(comments) == 0 {
+			continue
+		}
+		w.comments[file.Path] = comments
+	}
+	return nil
+}
+
+// rescan scans a single changed file against HEAD and removes its new comments with --fix
+func (w *Watcher) rescan(path string) []Event {
+	delete(w.comments, path)
+	scanner, err := comment.GetScanner(path)
+	if err != nil || w.ignored(path) {
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		// Removed or renamed away, editors also replace files while saving
+		return nil
+	}
+	file := source.File{Path: path, Content: content, Status: source.StatusAdded}
+	head, tracked, err := source.HeadFile(path)
+	if err != nil {
+		return []Event{warning("⚠️  Error reading %s from HEAD: %v", path, err)}
+	}
+	if tracked {
+		file.Status = source.StatusModified
+		file.DiffRanges = source.AddedLineRanges(head, content)
+	}
+
+	comments, err := scanner.Scan(file)
+	if err != nil {
+		return []Event{warning("⚠️  Parse error scanning %s: %v", path, err)}
+	}
+	comments = comment.WithoutKept(comments)
+	if len(comments) == 0 {
+		return nil
+	}
+	if !w.opts.Fix {
+		w.comments[path] = comments
+		return nil
+	}
+
+	if err := trash(path, comments); err != nil {
+		w.comments[path] = comments
+		var verifyErr *comment.VerifyError
+		if errors.As(err, &verifyErr) {
+			return []Event{warning("⚠️  Skipping %s, file left untouched: %s", path, verifyErr.Reason)}
+		}
+		return []Event{warning("⚠️  Error deleting comments in %s: %v", path, err)}
+	}
+	return []Event{{Time: time.Now(), Message: fmt.Sprintf("✅ Removed %d comments from %s", len(comments), path)}}
+}
+
+// trash removes comments like walle fix, journaled so walle undo restores them
+func trash(path string, comments []comment.Comment) error {
+	edit, err := comment.PlanRemoval(path, comments)
+	if err != nil {
+		return err
+	}
+	_, err = journal.Write(edit)
+	return err
+}
+
+func (w *Watcher) update(events []Event) Update {
+	return Update{Time: time.Now(), Comments: maps.Clone(w.comments), Events: events}
+}
+
+// send delivers an update unless the watch is stopped first
+func send(updates chan<- Update, stop <-chan struct{}, update Update) bool {
+	select {
+	case updates <- update:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+func warning(format string, args ...any) Event {
+	return Event{Time: time.Now(), Message: fmt.Sprintf(format, args...), Warning: true}
+}
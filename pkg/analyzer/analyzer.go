@@ -0,0 +1,196 @@
+// Package analyzer reports comments as a golang.org/x/tools/go/analysis Analyzer, so walle can run
+// inside go vet, gopls or golangci-lint. Comments are found and classified by the same tree-sitter
+// scanner as walle scan, and every report suggests the removal walle fix would make.
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+	"walle/internal/comment"
+	"walle/internal/source"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Analyzer reports the comments of the kinds given by its -kinds flag
+var Analyzer = newAnalyzer(&config{kinds: "line,block", skipGenerated: true})
+
+// Settings configures an analyzer built by New, golangci-lint decodes them from its linter settings
+type Settings struct {
+	// Kinds lists the comment kinds to report: line, block or doc. Empty reports line and block comments.
+	Kinds []string `json:"kinds"`
+	// IncludeGenerated also reports comments in files with a // Code generated ... DO NOT EDIT. header
+	IncludeGenerated bool `json:"include-generated"`
+}
+
+// New builds an analyzer from settings instead of flags
+func New(settings Settings) (*analysis.Analyzer, error) {
+	cfg := &config{kinds: "line,block", skipGenerated: !settings.IncludeGenerated}
+	if len(settings.Kinds) > 0 {
+		cfg.kinds = strings.Join(settings.Kinds, ",")
+	}
+	if _, err := parseKinds(cfg.kinds); err != nil {
+		return nil, err
+	}
+	return newAnalyzer(cfg), nil
+}
+
+// config holds the options of an analyzer, bound to its flags
+type config struct {
+	kinds         string
+	skipGenerated bool
+}
+
+func newAnalyzer(cfg *config) *analysis.Analyzer {
+	a := &analysis.Analyzer{
+		Name: "walle",
+		Doc:  "report comments and suggest fixes that remove them\n\nDirectives such as //go:build and //nolint are never reported, neither are comments marked walle:keep.",
+		URL:  "https://github.com/kallepronk/wall-e",
+		Run:  cfg.run,
+	}
+	a.Flags.StringVar(&cfg.kinds, "kinds", cfg.kinds, "comma separated comment kinds to report: line, block or doc")
+	a.Flags.BoolVar(&cfg.skipGenerated, "skip-generated", cfg.skipGenerated, "skip files with a // Code generated ... DO NOT EDIT. header")
+	return a
+}
+
+func (cfg *config) run(pass *analysis.Pass) (any, error) {
+	selected, err := parseKinds(cfg.kinds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range pass.Files {
+		if cfg.skipGenerated && ast.IsGenerated(file) {
+			continue
+		}
+		tokFile := pass.Fset.File(file.Pos())
+		if tokFile == nil {
+			continue
+		}
+		path := tokFile.Name()
+		content, err := pass.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		// Only report on what the compiler saw, cgo and //line directives map positions elsewhere
+		if tokFile.Size() != len(content) {
+			continue
+		}
+
+		scanner, err := comment.GetScanner(path)
+		if err != nil {
+			continue
+		}
+		comments, err := scanner.Scan(source.File{Path: path, Content: content, Status: source.StatusAdded})
+		if err != nil {
+			return nil, err
+		}
+		docs := docComments(tokFile, file)
+		for _, c := range comment.WithoutKept(comments) {
+			if docs[c.StartByte] && c.Kind != comment.KindDirective {
+				c.Kind = comment.KindDoc
+			}
+			if !selected[c.Kind] {
+				continue
+			}
+			pass.Report(diagnostic(tokFile, path, content, c))
+		}
+	}
+	return nil, nil
+}
+
+// docComments returns the offsets of the comments in the doc comment groups of a file, its package
+// clause, declarations, specs and fields. The scanner only sees a // line above a declaration.
+func docComments(tokFile *token.File, file *ast.File) map[uint32]bool {
+	docs := make(map[uint32]bool)
+	add := func(group *ast.CommentGroup) {
+		if group == nil {
+			return
+		}
+		for _, c := range group.List {
+			docs[uint32(tokFile.Offset(c.Pos()))] = true
+		}
+	}
+	add(file.Doc)
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncDecl:
+			add(n.Doc)
+		case *ast.GenDecl:
+			add(n.Doc)
+		case *ast.TypeSpec:
+			add(n.Doc)
+		case *ast.ValueSpec:
+			add(n.Doc)
+		case *ast.ImportSpec:
+			add(n.Doc)
+		case *ast.Field:
+			add(n.Doc)
+		}
+		return true
+	})
+	return docs
+}
+
+// diagnostic reports a comment with the fix walle fix would make for it alone. A removal that
+// would change the syntax tree is reported without a fix.
+func diagnostic(tokFile *token.File, path string, content []byte, c comment.Comment) analysis.Diagnostic {
+	d := analysis.Diagnostic{
+		Pos:      tokFile.Pos(int(c.StartByte)),
+		End:      tokFile.Pos(int(c.EndByte)),
+		Category: c.Kind.String(),
+		Message:  fmt.Sprintf("%s comment %s can be removed", c.Kind, c.ID),
+	}
+
+	updated, _ := comment.ApplyRemovals(path, content, []comment.Comment{c})
+	if err := comment.Verify(path, content, updated); err != nil {
+		return d
+	}
+	start, end, text := minimalEdit(content, updated)
+	d.SuggestedFixes = []analysis.SuggestedFix{{
+		Message: "Remove comment",
+		TextEdits: []analysis.TextEdit{{
+			Pos:     tokFile.Pos(start),
+			End:     tokFile.Pos(end),
+			NewText: text,
+		}},
+	}}
+	return d
+}
+
+// minimalEdit returns the range of original that is replaced by text to get updated
+func minimalEdit(original, updated []byte) (int, int, []byte) {
+	prefix := 0
+	for prefix < len(original) && prefix < len(updated) && original[prefix] == updated[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(original)-prefix && suffix < len(updated)-prefix &&
+		original[len(original)-1-suffix] == updated[len(updated)-1-suffix] {
+		suffix++
+	}
+	return prefix, len(original) - suffix, bytes.Clone(updated[prefix : len(updated)-suffix])
+}
+
+// parseKinds turns the -kinds flag into a set, directives can't be selected
+func parseKinds(value string) (map[comment.Kind]bool, error) {
+	selected := make(map[comment.Kind]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		kind, err := comment.ParseKind(name)
+		if err != nil {
+			return nil, err
+		}
+		if kind == comment.KindDirective {
+			return nil, fmt.Errorf("directive comments can't be reported, removing them changes the build")
+		}
+		selected[kind] = true
+	}
+	return selected, nil
+}
@@ -0,0 +1,35 @@
+package analyzer
+
+import (
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis"
+)
+
+func init() {
+	register.Plugin("walle", newPlugin)
+}
+
+// plugin adds the analyzer to a custom golangci-lint build, see golangci-lint's module plugin system
+type plugin struct {
+	settings Settings
+}
+
+func newPlugin(conf any) (register.LinterPlugin, error) {
+	settings, err := register.DecodeSettings[Settings](conf)
+	if err != nil {
+		return nil, err
+	}
+	return &plugin{settings: settings}, nil
+}
+
+func (p *plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	a, err := New(p.settings)
+	if err != nil {
+		return nil, err
+	}
+	return []*analysis.Analyzer{a}, nil
+}
+
+func (p *plugin) GetLoadMode() string {
+	return register.LoadModeSyntax
+}
@@ -0,0 +1,19 @@
+// Package walle finds and removes comments in source code, the library behind the walle command.
+//
+// Scan and Fix work on files in the git repository of the current directory, ScanBytes and FixBytes
+// on content in memory. Nothing is printed, results are returned as values and progress is reported
+// through Options.OnEvent.
+//
+// # Compatibility
+//
+// The package follows semantic versioning, APIVersion is its major version. Within a major version:
+//   - exported identifiers are not removed or renamed and function signatures don't change
+//   - fields and event types may be added, so use keyed struct literals and ignore unknown events
+//   - comment IDs stay the same for the same path, text and enclosing symbol
+//   - a fix that would change the syntax tree of a file is never written
+//
+// Breaking changes move the package to a new major version path such as walle/pkg/walle/v2.
+package walle
+
+// APIVersion is the major version of this package's API
+const APIVersion = 1
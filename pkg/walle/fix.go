@@ -0,0 +1,112 @@
+package walle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"walle/internal/comment"
+	"walle/internal/journal"
+	"walle/internal/pipeline"
+)
+
+// Fix removes comments from their files. Every file is verified to keep its syntax tree, files
+// that would change, or that changed since they were scanned, are skipped with the reason.
+// Unless NoJournal is set the original content is journaled first so walle undo restores it.
+func Fix(ctx context.Context, comments []Comment, opts FixOptions) (FixResult, error) {
+	var result FixResult
+	pipeOpts := pipeline.Options{
+		Context: ctx,
+		OnEvent: func(event pipeline.Event) {
+			switch event.Type {
+			case pipeline.EventWarning:
+				emit(opts.OnEvent, Event{Type: EventWarning, Path: event.Path, Message: skipReason(event.Err)})
+			case pipeline.EventRepair:
+				emit(opts.OnEvent, Event{Type: EventRepair, Path: event.Path, Line: event.Line, Message: event.Message})
+			case pipeline.EventFileWritten:
+				emit(opts.OnEvent, Event{Type: EventFileFixed, Path: event.Path, Count: event.Count})
+			}
+		},
+	}
+	plan, err := pipeline.Plan(toComments(comments), nil, pipeOpts)
+	if err != nil {
+		return FixResult{}, err
+	}
+	for _, skipped := range plan.Skipped {
+		result.Files = append(result.Files, FileResult{Path: skipped.Path, Skipped: skipReason(skipped.Err)})
+	}
+
+	failed := make([]error, len(plan.Edits))
+	var journalErr error
+	if !opts.DryRun {
+		var run journal.Run
+		run, failed, journalErr = pipeline.Write(plan.Edits, !opts.NoJournal, pipeOpts)
+		if journalErr != nil && run.ID == "" {
+			return FixResult{}, journalErr
+		}
+		result.RunID = run.ID
+	}
+
+	for i, edit := range plan.Edits {
+		file := FileResult{Path: edit.Path, Original: edit.Original, Updated: edit.Updated}
+		if failed[i] != nil {
+			file.Skipped = failed[i].Error()
+			result.Files = append(result.Files, file)
+			continue
+		}
+		file.Removed = len(edit.Comments)
+		for _, repair := range edit.Repairs {
+			file.Repairs = append(file.Repairs, Repair{Line: repair.Line, Description: repair.Description})
+		}
+		result.Files = append(result.Files, file)
+	}
+
+	sort.SliceStable(result.Files, func(i, j int) bool {
+		return result.Files[i].Path < result.Files[j].Path
+	})
+	return result, journalErr
+}
+
+// FixBytes returns content without the given comments, or without every comment when comments is nil.
+// path selects the language by its extension. Comments outside content and a removal that would change the
+// syntax tree return an error.
+func FixBytes(path string, content []byte, comments []Comment) ([]byte, []Repair, error) {
+	if comments == nil {
+		var err error
+		comments, err = ScanBytes(path, content)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, c := range comments {
+		if c.StartByte < 0 || c.StartByte > c.EndByte || c.EndByte > len(content) {
+			return nil, nil, fmt.Errorf("%s: comment at bytes %d-%d is outside the %d bytes of content", path, c.StartByte, c.EndByte, len(content))
+		}
+	}
+	internal := toComments(comments)
+	if err := comment.CheckScanned(path, content, internal); err != nil {
+		return nil, nil, err
+	}
+
+	updated, repairs := comment.ApplyRemovals(path, content, internal)
+	if err := comment.Verify(path, content, updated); err != nil {
+		return nil, nil, err
+	}
+	var result []Repair
+	for _, repair := range repairs {
+		result = append(result, Repair{Line: repair.Line, Description: repair.Description})
+	}
+	return updated, result, nil
+}
+
+// skipReason explains why a file was left untouched
+func skipReason(err error) string {
+	var verifyErr *comment.VerifyError
+	if errors.As(err, &verifyErr) {
+		return verifyErr.Reason
+	}
+	if errors.Is(err, comment.ErrModified) {
+		return "changed since it was scanned"
+	}
+	return err.Error()
+}
@@ -0,0 +1,129 @@
+package walle
+
+import "walle/internal/comment"
+
+// Kind is the sort of a comment: line, block, doc or directive
+type Kind string
+
+const (
+	KindLine      Kind = "line"
+	KindBlock     Kind = "block"
+	KindDoc       Kind = "doc"
+	KindDirective Kind = "directive"
+)
+
+// Scope selects which comments Scan reports
+type Scope int
+
+const (
+	// ScopeChanges reports comments on lines added relative to HEAD, or to Base when it is set
+	ScopeChanges Scope = iota
+	// ScopeAll reports every comment in the scanned files
+	ScopeAll
+)
+
+// Comment is a comment found by Scan or ScanBytes
+type Comment struct {
+	// ID identifies the comment across scans and edits of unrelated code
+	ID   string
+	Path string
+	// Line is 1-based, StartByte and EndByte are offsets into the file content
+	Line      int
+	StartByte int
+	EndByte   int
+	Kind      Kind
+	// Symbol is the qualified name of the declaration enclosing the comment, if any
+	Symbol string
+	Text   string
+	// Redundancy scores from 0 to 1 how much the comment only repeats the code it describes
+	Redundancy float64
+
+	// hash is the sha256 of the content the comment was found in, Fix skips files that changed since
+	hash string
+}
+
+// Options configures Scan
+type Options struct {
+	// Paths limits the scan to these files and directories, relative to the current directory.
+	// Without paths the changed files of the worktree are scanned, or every file with ScopeAll.
+	// Files named directly are scanned even when .gitignore excludes them, like the scan command does.
+	Paths []string
+	Scope Scope
+	// Base and Target compare two commits instead of the worktree, they can't be combined with Paths
+	Base   string
+	Target string
+	// IgnoreGitIgnore includes files excluded by .gitignore
+	IgnoreGitIgnore bool
+	// OnEvent receives progress events, it may be nil
+	OnEvent func(Event)
+}
+
+// Result is the outcome of Scan
+type Result struct {
+	Comments []Comment
+	// Files is the number of files that were scanned
+	Files int
+}
+
+// FixOptions configures Fix
+type FixOptions struct {
+	// DryRun computes the fixed content without writing it
+	DryRun bool
+	// NoJournal skips recording the original content, the fix then can't be undone with walle undo
+	NoJournal bool
+	// OnEvent receives progress events, it may be nil
+	OnEvent func(Event)
+}
+
+// FixResult is the outcome of Fix
+type FixResult struct {
+	Files []FileResult
+	// RunID identifies the fix for walle undo, empty for dry runs, with NoJournal and when no file was written
+	RunID string
+}
+
+// FileResult is the outcome of fixing a single file
+type FileResult struct {
+	Path    string
+	Removed int
+	Repairs []Repair
+	// Skipped explains why the file was left untouched, empty when it was fixed
+	Skipped  string
+	Original []byte
+	Updated  []byte
+}
+
+// Repair is a change made around a removed comment so the file stays valid
+type Repair struct {
+	Line        int
+	Description string
+}
+
+// EventType tells what an Event reports
+type EventType string
+
+const (
+	// EventFileScanned is sent for every scanned file, Count holds its number of comments
+	EventFileScanned EventType = "file_scanned"
+	// EventWarning is sent for a file that could not be scanned or fixed, Message holds the reason
+	EventWarning EventType = "warning"
+	// EventFileFixed is sent for every fixed file, Count holds the number of removed comments
+	EventFileFixed EventType = "file_fixed"
+	// EventRepair is sent for every repair made while fixing, Line and Message describe it
+	EventRepair EventType = "repair"
+)
+
+// Event reports progress of Scan or Fix
+type Event struct {
+	Type    EventType
+	Path    string
+	Line    int
+	Count   int
+	Message string
+}
+
+// ErrModified is returned, wrapped, by FixBytes for content that differs from what the comments were found in
+var ErrModified = comment.ErrModified
+
+// VerifyError is returned by FixBytes for a removal that would change the syntax tree of the content
+type VerifyError = comment.VerifyError
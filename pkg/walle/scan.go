@@ -0,0 +1,103 @@
+package walle
+
+import (
+	"context"
+	"sort"
+	"walle/internal/comment"
+	"walle/internal/pipeline"
+	"walle/internal/source"
+)
+
+// Scan finds the comments selected by opts in the git repository of the current directory.
+// Files that can't be parsed are reported as EventWarning and left out of the result.
+func Scan(ctx context.Context, opts Options) (Result, error) {
+	scanOpts, err := source.BuildScanOptions(opts.Scope == ScopeAll, "", opts.IgnoreGitIgnore, opts.Base, opts.Target, opts.Paths)
+	if err != nil {
+		return Result{}, err
+	}
+	result, err := pipeline.Scan(scanOpts, pipeline.Options{
+		Context: ctx,
+		OnEvent: func(event pipeline.Event) {
+			switch event.Type {
+			case pipeline.EventFileScanned:
+				emit(opts.OnEvent, Event{Type: EventFileScanned, Path: event.Path, Count: event.Count})
+			case pipeline.EventWarning:
+				emit(opts.OnEvent, Event{Type: EventWarning, Path: event.Path, Message: event.Err.Error()})
+			}
+		},
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Comments: fromComments(result.Comments()), Files: result.Scanned}, nil
+}
+
+// ScanBytes finds every comment in content except those with the keep marker, path selects the language
+// by its extension
+func ScanBytes(path string, content []byte) ([]Comment, error) {
+	scanner, err := comment.GetScanner(path)
+	if err != nil {
+		return nil, err
+	}
+	found, err := scanner.Scan(source.File{Path: path, Content: content, Status: source.StatusAdded})
+	if err != nil {
+		return nil, err
+	}
+	comments := fromComments(comment.WithoutKept(found))
+	sortComments(comments)
+	return comments, nil
+}
+
+func fromComments(found []comment.Comment) []Comment {
+	comments := make([]Comment, 0, len(found))
+	for _, c := range found {
+		comments = append(comments, Comment{
+			ID:         c.ID,
+			Path:       c.FilePath,
+			Line:       c.Line,
+			StartByte:  int(c.StartByte),
+			EndByte:    int(c.EndByte),
+			Kind:       Kind(c.Kind.String()),
+			Symbol:     c.Symbol,
+			Text:       c.Text,
+			Redundancy: c.Redundancy,
+			hash:       c.FileHash,
+		})
+	}
+	return comments
+}
+
+func toComments(comments []Comment) []comment.Comment {
+	internal := make([]comment.Comment, 0, len(comments))
+	for _, c := range comments {
+		kind, _ := comment.ParseKind(string(c.Kind))
+		internal = append(internal, comment.Comment{
+			FilePath:   c.Path,
+			Text:       c.Text,
+			Line:       c.Line,
+			StartByte:  uint32(c.StartByte),
+			EndByte:    uint32(c.EndByte),
+			Kind:       kind,
+			ID:         c.ID,
+			Symbol:     c.Symbol,
+			FileHash:   c.hash,
+			Redundancy: c.Redundancy,
+		})
+	}
+	return internal
+}
+
+func sortComments(comments []Comment) {
+	sort.SliceStable(comments, func(i, j int) bool {
+		if comments[i].Path != comments[j].Path {
+			return comments[i].Path < comments[j].Path
+		}
+		return comments[i].StartByte < comments[j].StartByte
+	})
+}
+
+func emit(onEvent func(Event), event Event) {
+	if onEvent != nil {
+		onEvent(event)
+	}
+}